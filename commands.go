@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+
+	"github.com/PacoDw/currency/repository"
+	"github.com/PacoDw/currency/server"
+	"github.com/pkg/errors"
+)
+
+// usage describes the commands accepted by the binary.
+const usage = `usage:
+  currency                      starts the server
+  currency migrate up           applies all the pending migrations
+  currency migrate down         rolls back the latest applied migration
+  currency migrate status       lists the migrations and whether they are applied
+  currency backfill --from 2022-01-01 --to 2022-10-01
+                                fills the days without rates using the historical rates of the provider`
+
+// runCommand runs the command passed as arguments instead of starting the server.
+func runCommand(repo *repository.SQLConnection, args []string) error {
+	switch args[0] {
+	case "migrate":
+		return migrate(repo, args[1:])
+	case "backfill":
+		return backfill(repo, args[1:])
+	default:
+		return errors.Errorf("unknown command (%s)\n%s", args[0], usage)
+	}
+}
+
+// migrate runs the migrate command with its subcommand: up, down or status.
+func migrate(repo *repository.SQLConnection, args []string) error {
+	if len(args) != 1 {
+		return errors.Errorf("the migrate command needs one subcommand\n%s", usage)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := repo.MigrateUp()
+
+		for i := range applied {
+			fmt.Printf("applied %04d_%s\n", applied[i].Version, applied[i].Name)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if len(applied) == 0 {
+			fmt.Println("the database is up to date")
+		}
+
+		return nil
+	case "down":
+		m, err := repo.MigrateDown()
+		if errors.Cause(err) == repository.ErrNotFound {
+			fmt.Println("there are no migrations to roll back")
+
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("rolled back %04d_%s\n", m.Version, m.Name)
+
+		return nil
+	case "status":
+		status, err := repo.MigrationStatus()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+
+		for i := range status {
+			appliedAt := "pending"
+			if status[i].Applied {
+				appliedAt = status[i].AppliedAt.Format("2006-01-02T15:04:05")
+			}
+
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", status[i].Version, status[i].Name, appliedAt)
+		}
+
+		return w.Flush()
+	default:
+		return errors.Errorf("unknown migrate subcommand (%s)\n%s", args[0], usage)
+	}
+}
+
+// backfill runs the backfill command between the dates passed with the --from and --to flags, an
+// interrupted backfill is resumed running it again with the same dates.
+func backfill(repo *repository.SQLConnection, args []string) error {
+	var from, to string
+
+	fs := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	fs.StringVar(&from, "from", "", "the first day to backfill, e.g.: 2022-01-01")
+	fs.StringVar(&to, "to", "", "the last day to backfill, e.g.: 2022-10-01")
+
+	if err := fs.Parse(args); err != nil {
+		return errors.Wrapf(err, "invalid backfill flags\n%s", usage)
+	}
+
+	if from == "" || to == "" {
+		return errors.Errorf("the backfill command needs the --from and --to flags\n%s", usage)
+	}
+
+	fromDay, err := time.Parse(server.DateLayout, from)
+	if err != nil {
+		return errors.Wrapf(err, "the --from flag must be a date like %s", server.DateLayout)
+	}
+
+	toDay, err := time.Parse(server.DateLayout, to)
+	if err != nil {
+		return errors.Wrapf(err, "the --to flag must be a date like %s", server.DateLayout)
+	}
+
+	if err := repo.CheckSchemaVersion(); err != nil {
+		return err
+	}
+
+	s := server.New(
+		server.Repository(repo.RequestStatus, repo.CurrencyValue),
+		server.CurrencyProvider(newCurrencyProvider()),
+	)
+
+	// the days stored before the interruption are kept
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result, err := s.Backfill(ctx, fromDay, toDay)
+
+	fmt.Printf("stored %d days, skipped %d days already stored, failed %d days\n", result.Stored, result.Skipped, result.Failed)
+
+	return err
+}
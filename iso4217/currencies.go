@@ -0,0 +1,222 @@
+package iso4217
+
+// digits returns a pointer to the number of minor units.
+func digits(n int) *int {
+	return &n
+}
+
+// registry holds the ISO 4217 currencies, the active ones follow the current list published by the
+// maintenance agency and the withdrawn ones are the most common historic codes, a new amendment
+// should be added here.
+var registry = []Currency{
+	// active currencies
+	{Code: "AED", Numeric: "784", Name: "UAE Dirham", MinorUnits: digits(2), Status: Active},
+	{Code: "AFN", Numeric: "971", Name: "Afghani", MinorUnits: digits(2), Status: Active},
+	{Code: "ALL", Numeric: "008", Name: "Lek", MinorUnits: digits(2), Status: Active},
+	{Code: "AMD", Numeric: "051", Name: "Armenian Dram", MinorUnits: digits(2), Status: Active},
+	{Code: "AOA", Numeric: "973", Name: "Kwanza", MinorUnits: digits(2), Status: Active},
+	{Code: "ARS", Numeric: "032", Name: "Argentine Peso", MinorUnits: digits(2), Status: Active},
+	{Code: "AUD", Numeric: "036", Name: "Australian Dollar", MinorUnits: digits(2), Status: Active},
+	{Code: "AWG", Numeric: "533", Name: "Aruban Florin", MinorUnits: digits(2), Status: Active},
+	{Code: "AZN", Numeric: "944", Name: "Azerbaijan Manat", MinorUnits: digits(2), Status: Active},
+	{Code: "BAM", Numeric: "977", Name: "Convertible Mark", MinorUnits: digits(2), Status: Active},
+	{Code: "BBD", Numeric: "052", Name: "Barbados Dollar", MinorUnits: digits(2), Status: Active},
+	{Code: "BDT", Numeric: "050", Name: "Taka", MinorUnits: digits(2), Status: Active},
+	{Code: "BHD", Numeric: "048", Name: "Bahraini Dinar", MinorUnits: digits(3), Status: Active},
+	{Code: "BIF", Numeric: "108", Name: "Burundi Franc", MinorUnits: digits(0), Status: Active},
+	{Code: "BMD", Numeric: "060", Name: "Bermudian Dollar", MinorUnits: digits(2), Status: Active},
+	{Code: "BND", Numeric: "096", Name: "Brunei Dollar", MinorUnits: digits(2), Status: Active},
+	{Code: "BOB", Numeric: "068", Name: "Boliviano", MinorUnits: digits(2), Status: Active},
+	{Code: "BOV", Numeric: "984", Name: "Mvdol", MinorUnits: digits(2), Status: Active},
+	{Code: "BRL", Numeric: "986", Name: "Brazilian Real", MinorUnits: digits(2), Status: Active},
+	{Code: "BSD", Numeric: "044", Name: "Bahamian Dollar", MinorUnits: digits(2), Status: Active},
+	{Code: "BTN", Numeric: "064", Name: "Ngultrum", MinorUnits: digits(2), Status: Active},
+	{Code: "BWP", Numeric: "072", Name: "Pula", MinorUnits: digits(2), Status: Active},
+	{Code: "BYN", Numeric: "933", Name: "Belarusian Ruble", MinorUnits: digits(2), Status: Active},
+	{Code: "BZD", Numeric: "084", Name: "Belize Dollar", MinorUnits: digits(2), Status: Active},
+	{Code: "CAD", Numeric: "124", Name: "Canadian Dollar", MinorUnits: digits(2), Status: Active},
+	{Code: "CDF", Numeric: "976", Name: "Congolese Franc", MinorUnits: digits(2), Status: Active},
+	{Code: "CHE", Numeric: "947", Name: "WIR Euro", MinorUnits: digits(2), Status: Active},
+	{Code: "CHF", Numeric: "756", Name: "Swiss Franc", MinorUnits: digits(2), Status: Active},
+	{Code: "CHW", Numeric: "948", Name: "WIR Franc", MinorUnits: digits(2), Status: Active},
+	{Code: "CLF", Numeric: "990", Name: "Unidad de Fomento", MinorUnits: digits(4), Status: Active},
+	{Code: "CLP", Numeric: "152", Name: "Chilean Peso", MinorUnits: digits(0), Status: Active},
+	{Code: "CNY", Numeric: "156", Name: "Yuan Renminbi", MinorUnits: digits(2), Status: Active},
+	{Code: "COP", Numeric: "170", Name: "Colombian Peso", MinorUnits: digits(2), Status: Active},
+	{Code: "COU", Numeric: "970", Name: "Unidad de Valor Real", MinorUnits: digits(2), Status: Active},
+	{Code: "CRC", Numeric: "188", Name: "Costa Rican Colon", MinorUnits: digits(2), Status: Active},
+	{Code: "CUC", Numeric: "931", Name: "Peso Convertible", MinorUnits: digits(2), Status: Active},
+	{Code: "CUP", Numeric: "192", Name: "Cuban Peso", MinorUnits: digits(2), Status: Active},
+	{Code: "CVE", Numeric: "132", Name: "Cabo Verde Escudo", MinorUnits: digits(2), Status: Active},
+	{Code: "CZK", Numeric: "203", Name: "Czech Koruna", MinorUnits: digits(2), Status: Active},
+	{Code: "DJF", Numeric: "262", Name: "Djibouti Franc", MinorUnits: digits(0), Status: Active},
+	{Code: "DKK", Numeric: "208", Name: "Danish Krone", MinorUnits: digits(2), Status: Active},
+	{Code: "DOP", Numeric: "214", Name: "Dominican Peso", MinorUnits: digits(2), Status: Active},
+	{Code: "DZD", Numeric: "012", Name: "Algerian Dinar", MinorUnits: digits(2), Status: Active},
+	{Code: "EGP", Numeric: "818", Name: "Egyptian Pound", MinorUnits: digits(2), Status: Active},
+	{Code: "ERN", Numeric: "232", Name: "Nakfa", MinorUnits: digits(2), Status: Active},
+	{Code: "ETB", Numeric: "230", Name: "Ethiopian Birr", MinorUnits: digits(2), Status: Active},
+	{Code: "EUR", Numeric: "978", Name: "Euro", MinorUnits: digits(2), Status: Active},
+	{Code: "FJD", Numeric: "242", Name: "Fiji Dollar", MinorUnits: digits(2), Status: Active},
+	{Code: "FKP", Numeric: "238", Name: "Falkland Islands Pound", MinorUnits: digits(2), Status: Active},
+	{Code: "GBP", Numeric: "826", Name: "Pound Sterling", MinorUnits: digits(2), Status: Active},
+	{Code: "GEL", Numeric: "981", Name: "Lari", MinorUnits: digits(2), Status: Active},
+	{Code: "GHS", Numeric: "936", Name: "Ghana Cedi", MinorUnits: digits(2), Status: Active},
+	{Code: "GIP", Numeric: "292", Name: "Gibraltar Pound", MinorUnits: digits(2), Status: Active},
+	{Code: "GMD", Numeric: "270", Name: "Dalasi", MinorUnits: digits(2), Status: Active},
+	{Code: "GNF", Numeric: "324", Name: "Guinean Franc", MinorUnits: digits(0), Status: Active},
+	{Code: "GTQ", Numeric: "320", Name: "Quetzal", MinorUnits: digits(2), Status: Active},
+	{Code: "GYD", Numeric: "328", Name: "Guyana Dollar", MinorUnits: digits(2), Status: Active},
+	{Code: "HKD", Numeric: "344", Name: "Hong Kong Dollar", MinorUnits: digits(2), Status: Active},
+	{Code: "HNL", Numeric: "340", Name: "Lempira", MinorUnits: digits(2), Status: Active},
+	{Code: "HTG", Numeric: "332", Name: "Gourde", MinorUnits: digits(2), Status: Active},
+	{Code: "HUF", Numeric: "348", Name: "Forint", MinorUnits: digits(2), Status: Active},
+	{Code: "IDR", Numeric: "360", Name: "Rupiah", MinorUnits: digits(2), Status: Active},
+	{Code: "ILS", Numeric: "376", Name: "New Israeli Sheqel", MinorUnits: digits(2), Status: Active},
+	{Code: "INR", Numeric: "356", Name: "Indian Rupee", MinorUnits: digits(2), Status: Active},
+	{Code: "IQD", Numeric: "368", Name: "Iraqi Dinar", MinorUnits: digits(3), Status: Active},
+	{Code: "IRR", Numeric: "364", Name: "Iranian Rial", MinorUnits: digits(2), Status: Active},
+	{Code: "ISK", Numeric: "352", Name: "Iceland Krona", MinorUnits: digits(0), Status: Active},
+	{Code: "JMD", Numeric: "388", Name: "Jamaican Dollar", MinorUnits: digits(2), Status: Active},
+	{Code: "JOD", Numeric: "400", Name: "Jordanian Dinar", MinorUnits: digits(3), Status: Active},
+	{Code: "JPY", Numeric: "392", Name: "Yen", MinorUnits: digits(0), Status: Active},
+	{Code: "KES", Numeric: "404", Name: "Kenyan Shilling", MinorUnits: digits(2), Status: Active},
+	{Code: "KGS", Numeric: "417", Name: "Som", MinorUnits: digits(2), Status: Active},
+	{Code: "KHR", Numeric: "116", Name: "Riel", MinorUnits: digits(2), Status: Active},
+	{Code: "KMF", Numeric: "174", Name: "Comorian Franc", MinorUnits: digits(0), Status: Active},
+	{Code: "KPW", Numeric: "408", Name: "North Korean Won", MinorUnits: digits(2), Status: Active},
+	{Code: "KRW", Numeric: "410", Name: "Won", MinorUnits: digits(0), Status: Active},
+	{Code: "KWD", Numeric: "414", Name: "Kuwaiti Dinar", MinorUnits: digits(3), Status: Active},
+	{Code: "KYD", Numeric: "136", Name: "Cayman Islands Dollar", MinorUnits: digits(2), Status: Active},
+	{Code: "KZT", Numeric: "398", Name: "Tenge", MinorUnits: digits(2), Status: Active},
+	{Code: "LAK", Numeric: "418", Name: "Lao Kip", MinorUnits: digits(2), Status: Active},
+	{Code: "LBP", Numeric: "422", Name: "Lebanese Pound", MinorUnits: digits(2), Status: Active},
+	{Code: "LKR", Numeric: "144", Name: "Sri Lanka Rupee", MinorUnits: digits(2), Status: Active},
+	{Code: "LRD", Numeric: "430", Name: "Liberian Dollar", MinorUnits: digits(2), Status: Active},
+	{Code: "LSL", Numeric: "426", Name: "Loti", MinorUnits: digits(2), Status: Active},
+	{Code: "LYD", Numeric: "434", Name: "Libyan Dinar", MinorUnits: digits(3), Status: Active},
+	{Code: "MAD", Numeric: "504", Name: "Moroccan Dirham", MinorUnits: digits(2), Status: Active},
+	{Code: "MDL", Numeric: "498", Name: "Moldovan Leu", MinorUnits: digits(2), Status: Active},
+	{Code: "MGA", Numeric: "969", Name: "Malagasy Ariary", MinorUnits: digits(2), Status: Active},
+	{Code: "MKD", Numeric: "807", Name: "Denar", MinorUnits: digits(2), Status: Active},
+	{Code: "MMK", Numeric: "104", Name: "Kyat", MinorUnits: digits(2), Status: Active},
+	{Code: "MNT", Numeric: "496", Name: "Tugrik", MinorUnits: digits(2), Status: Active},
+	{Code: "MOP", Numeric: "446", Name: "Pataca", MinorUnits: digits(2), Status: Active},
+	{Code: "MRU", Numeric: "929", Name: "Ouguiya", MinorUnits: digits(2), Status: Active},
+	{Code: "MUR", Numeric: "480", Name: "Mauritius Rupee", MinorUnits: digits(2), Status: Active},
+	{Code: "MVR", Numeric: "462", Name: "Rufiyaa", MinorUnits: digits(2), Status: Active},
+	{Code: "MWK", Numeric: "454", Name: "Malawi Kwacha", MinorUnits: digits(2), Status: Active},
+	{Code: "MXN", Numeric: "484", Name: "Mexican Peso", MinorUnits: digits(2), Status: Active},
+	{Code: "MXV", Numeric: "979", Name: "Mexican Unidad de Inversion (UDI)", MinorUnits: digits(2), Status: Active},
+	{Code: "MYR", Numeric: "458", Name: "Malaysian Ringgit", MinorUnits: digits(2), Status: Active},
+	{Code: "MZN", Numeric: "943", Name: "Mozambique Metical", MinorUnits: digits(2), Status: Active},
+	{Code: "NAD", Numeric: "516", Name: "Namibia Dollar", MinorUnits: digits(2), Status: Active},
+	{Code: "NGN", Numeric: "566", Name: "Naira", MinorUnits: digits(2), Status: Active},
+	{Code: "NIO", Numeric: "558", Name: "Cordoba Oro", MinorUnits: digits(2), Status: Active},
+	{Code: "NOK", Numeric: "578", Name: "Norwegian Krone", MinorUnits: digits(2), Status: Active},
+	{Code: "NPR", Numeric: "524", Name: "Nepalese Rupee", MinorUnits: digits(2), Status: Active},
+	{Code: "NZD", Numeric: "554", Name: "New Zealand Dollar", MinorUnits: digits(2), Status: Active},
+	{Code: "OMR", Numeric: "512", Name: "Rial Omani", MinorUnits: digits(3), Status: Active},
+	{Code: "PAB", Numeric: "590", Name: "Balboa", MinorUnits: digits(2), Status: Active},
+	{Code: "PEN", Numeric: "604", Name: "Sol", MinorUnits: digits(2), Status: Active},
+	{Code: "PGK", Numeric: "598", Name: "Kina", MinorUnits: digits(2), Status: Active},
+	{Code: "PHP", Numeric: "608", Name: "Philippine Peso", MinorUnits: digits(2), Status: Active},
+	{Code: "PKR", Numeric: "586", Name: "Pakistan Rupee", MinorUnits: digits(2), Status: Active},
+	{Code: "PLN", Numeric: "985", Name: "Zloty", MinorUnits: digits(2), Status: Active},
+	{Code: "PYG", Numeric: "600", Name: "Guarani", MinorUnits: digits(0), Status: Active},
+	{Code: "QAR", Numeric: "634", Name: "Qatari Rial", MinorUnits: digits(2), Status: Active},
+	{Code: "RON", Numeric: "946", Name: "Romanian Leu", MinorUnits: digits(2), Status: Active},
+	{Code: "RSD", Numeric: "941", Name: "Serbian Dinar", MinorUnits: digits(2), Status: Active},
+	{Code: "RUB", Numeric: "643", Name: "Russian Ruble", MinorUnits: digits(2), Status: Active},
+	{Code: "RWF", Numeric: "646", Name: "Rwanda Franc", MinorUnits: digits(0), Status: Active},
+	{Code: "SAR", Numeric: "682", Name: "Saudi Riyal", MinorUnits: digits(2), Status: Active},
+	{Code: "SBD", Numeric: "090", Name: "Solomon Islands Dollar", MinorUnits: digits(2), Status: Active},
+	{Code: "SCR", Numeric: "690", Name: "Seychelles Rupee", MinorUnits: digits(2), Status: Active},
+	{Code: "SDG", Numeric: "938", Name: "Sudanese Pound", MinorUnits: digits(2), Status: Active},
+	{Code: "SEK", Numeric: "752", Name: "Swedish Krona", MinorUnits: digits(2), Status: Active},
+	{Code: "SGD", Numeric: "702", Name: "Singapore Dollar", MinorUnits: digits(2), Status: Active},
+	{Code: "SHP", Numeric: "654", Name: "Saint Helena Pound", MinorUnits: digits(2), Status: Active},
+	{Code: "SLE", Numeric: "925", Name: "Leone", MinorUnits: digits(2), Status: Active},
+	{Code: "SOS", Numeric: "706", Name: "Somali Shilling", MinorUnits: digits(2), Status: Active},
+	{Code: "SRD", Numeric: "968", Name: "Surinam Dollar", MinorUnits: digits(2), Status: Active},
+	{Code: "SSP", Numeric: "728", Name: "South Sudanese Pound", MinorUnits: digits(2), Status: Active},
+	{Code: "STN", Numeric: "930", Name: "Dobra", MinorUnits: digits(2), Status: Active},
+	{Code: "SVC", Numeric: "222", Name: "El Salvador Colon", MinorUnits: digits(2), Status: Active},
+	{Code: "SYP", Numeric: "760", Name: "Syrian Pound", MinorUnits: digits(2), Status: Active},
+	{Code: "SZL", Numeric: "748", Name: "Lilangeni", MinorUnits: digits(2), Status: Active},
+	{Code: "THB", Numeric: "764", Name: "Baht", MinorUnits: digits(2), Status: Active},
+	{Code: "TJS", Numeric: "972", Name: "Somoni", MinorUnits: digits(2), Status: Active},
+	{Code: "TMT", Numeric: "934", Name: "Turkmenistan New Manat", MinorUnits: digits(2), Status: Active},
+	{Code: "TND", Numeric: "788", Name: "Tunisian Dinar", MinorUnits: digits(3), Status: Active},
+	{Code: "TOP", Numeric: "776", Name: "Pa'anga", MinorUnits: digits(2), Status: Active},
+	{Code: "TRY", Numeric: "949", Name: "Turkish Lira", MinorUnits: digits(2), Status: Active},
+	{Code: "TTD", Numeric: "780", Name: "Trinidad and Tobago Dollar", MinorUnits: digits(2), Status: Active},
+	{Code: "TWD", Numeric: "901", Name: "New Taiwan Dollar", MinorUnits: digits(2), Status: Active},
+	{Code: "TZS", Numeric: "834", Name: "Tanzanian Shilling", MinorUnits: digits(2), Status: Active},
+	{Code: "UAH", Numeric: "980", Name: "Hryvnia", MinorUnits: digits(2), Status: Active},
+	{Code: "UGX", Numeric: "800", Name: "Uganda Shilling", MinorUnits: digits(0), Status: Active},
+	{Code: "USD", Numeric: "840", Name: "US Dollar", MinorUnits: digits(2), Status: Active},
+	{Code: "USN", Numeric: "997", Name: "US Dollar (Next day)", MinorUnits: digits(2), Status: Active},
+	{Code: "UYI", Numeric: "940", Name: "Uruguay Peso en Unidades Indexadas (UI)", MinorUnits: digits(0), Status: Active},
+	{Code: "UYU", Numeric: "858", Name: "Peso Uruguayo", MinorUnits: digits(2), Status: Active},
+	{Code: "UYW", Numeric: "927", Name: "Unidad Previsional", MinorUnits: digits(4), Status: Active},
+	{Code: "UZS", Numeric: "860", Name: "Uzbekistan Sum", MinorUnits: digits(2), Status: Active},
+	{Code: "VED", Numeric: "926", Name: "Bolivar Soberano", MinorUnits: digits(2), Status: Active},
+	{Code: "VES", Numeric: "928", Name: "Bolivar Soberano", MinorUnits: digits(2), Status: Active},
+	{Code: "VND", Numeric: "704", Name: "Dong", MinorUnits: digits(0), Status: Active},
+	{Code: "VUV", Numeric: "548", Name: "Vatu", MinorUnits: digits(0), Status: Active},
+	{Code: "WST", Numeric: "882", Name: "Tala", MinorUnits: digits(2), Status: Active},
+	{Code: "XAF", Numeric: "950", Name: "CFA Franc BEAC", MinorUnits: digits(0), Status: Active},
+	{Code: "XAG", Numeric: "961", Name: "Silver", MinorUnits: nil, Status: Active},
+	{Code: "XAU", Numeric: "959", Name: "Gold", MinorUnits: nil, Status: Active},
+	{Code: "XBA", Numeric: "955", Name: "Bond Markets Unit European Composite Unit (EURCO)", MinorUnits: nil, Status: Active},
+	{Code: "XBB", Numeric: "956", Name: "Bond Markets Unit European Monetary Unit (E.M.U.-6)", MinorUnits: nil, Status: Active},
+	{Code: "XBC", Numeric: "957", Name: "Bond Markets Unit European Unit of Account 9 (E.U.A.-9)", MinorUnits: nil, Status: Active},
+	{Code: "XBD", Numeric: "958", Name: "Bond Markets Unit European Unit of Account 17 (E.U.A.-17)", MinorUnits: nil, Status: Active},
+	{Code: "XCD", Numeric: "951", Name: "East Caribbean Dollar", MinorUnits: digits(2), Status: Active},
+	{Code: "XCG", Numeric: "532", Name: "Caribbean Guilder", MinorUnits: digits(2), Status: Active},
+	{Code: "XDR", Numeric: "960", Name: "SDR (Special Drawing Right)", MinorUnits: nil, Status: Active},
+	{Code: "XOF", Numeric: "952", Name: "CFA Franc BCEAO", MinorUnits: digits(0), Status: Active},
+	{Code: "XPD", Numeric: "964", Name: "Palladium", MinorUnits: nil, Status: Active},
+	{Code: "XPF", Numeric: "953", Name: "CFP Franc", MinorUnits: digits(0), Status: Active},
+	{Code: "XPT", Numeric: "962", Name: "Platinum", MinorUnits: nil, Status: Active},
+	{Code: "XSU", Numeric: "994", Name: "Sucre", MinorUnits: nil, Status: Active},
+	{Code: "XTS", Numeric: "963", Name: "Codes specifically reserved for testing purposes", MinorUnits: nil, Status: Active},
+	{Code: "XUA", Numeric: "965", Name: "ADB Unit of Account", MinorUnits: nil, Status: Active},
+	{Code: "XXX", Numeric: "999", Name: "The codes assigned for transactions where no currency is involved", MinorUnits: nil, Status: Active},
+	{Code: "YER", Numeric: "886", Name: "Yemeni Rial", MinorUnits: digits(2), Status: Active},
+	{Code: "ZAR", Numeric: "710", Name: "Rand", MinorUnits: digits(2), Status: Active},
+	{Code: "ZMW", Numeric: "967", Name: "Zambian Kwacha", MinorUnits: digits(2), Status: Active},
+	{Code: "ZWG", Numeric: "924", Name: "Zimbabwe Gold", MinorUnits: digits(2), Status: Active},
+
+	// withdrawn currencies
+	{Code: "ANG", Numeric: "532", Name: "Netherlands Antillean Guilder", MinorUnits: digits(2), Status: Withdrawn},
+	{Code: "ATS", Numeric: "040", Name: "Schilling", MinorUnits: digits(2), Status: Withdrawn},
+	{Code: "BEF", Numeric: "056", Name: "Belgian Franc", MinorUnits: digits(0), Status: Withdrawn},
+	{Code: "BGN", Numeric: "975", Name: "Bulgarian Lev", MinorUnits: digits(2), Status: Withdrawn},
+	{Code: "BYR", Numeric: "974", Name: "Belarusian Ruble", MinorUnits: digits(0), Status: Withdrawn},
+	{Code: "CYP", Numeric: "196", Name: "Cyprus Pound", MinorUnits: digits(2), Status: Withdrawn},
+	{Code: "DEM", Numeric: "276", Name: "Deutsche Mark", MinorUnits: digits(2), Status: Withdrawn},
+	{Code: "EEK", Numeric: "233", Name: "Kroon", MinorUnits: digits(2), Status: Withdrawn},
+	{Code: "ESP", Numeric: "724", Name: "Spanish Peseta", MinorUnits: digits(0), Status: Withdrawn},
+	{Code: "FIM", Numeric: "246", Name: "Markka", MinorUnits: digits(2), Status: Withdrawn},
+	{Code: "FRF", Numeric: "250", Name: "French Franc", MinorUnits: digits(2), Status: Withdrawn},
+	{Code: "GRD", Numeric: "300", Name: "Drachma", MinorUnits: digits(0), Status: Withdrawn},
+	{Code: "HRK", Numeric: "191", Name: "Kuna", MinorUnits: digits(2), Status: Withdrawn},
+	{Code: "IEP", Numeric: "372", Name: "Irish Pound", MinorUnits: digits(2), Status: Withdrawn},
+	{Code: "ITL", Numeric: "380", Name: "Italian Lira", MinorUnits: digits(0), Status: Withdrawn},
+	{Code: "LTL", Numeric: "440", Name: "Lithuanian Litas", MinorUnits: digits(2), Status: Withdrawn},
+	{Code: "LUF", Numeric: "442", Name: "Luxembourg Franc", MinorUnits: digits(0), Status: Withdrawn},
+	{Code: "LVL", Numeric: "428", Name: "Latvian Lats", MinorUnits: digits(2), Status: Withdrawn},
+	{Code: "MRO", Numeric: "478", Name: "Ouguiya", MinorUnits: digits(2), Status: Withdrawn},
+	{Code: "MTL", Numeric: "470", Name: "Maltese Lira", MinorUnits: digits(2), Status: Withdrawn},
+	{Code: "NLG", Numeric: "528", Name: "Netherlands Guilder", MinorUnits: digits(2), Status: Withdrawn},
+	{Code: "PTE", Numeric: "620", Name: "Portuguese Escudo", MinorUnits: digits(0), Status: Withdrawn},
+	{Code: "SIT", Numeric: "705", Name: "Tolar", MinorUnits: digits(2), Status: Withdrawn},
+	{Code: "SKK", Numeric: "703", Name: "Slovak Koruna", MinorUnits: digits(2), Status: Withdrawn},
+	{Code: "SLL", Numeric: "694", Name: "Leone", MinorUnits: digits(2), Status: Withdrawn},
+	{Code: "STD", Numeric: "678", Name: "Dobra", MinorUnits: digits(2), Status: Withdrawn},
+	{Code: "VEF", Numeric: "937", Name: "Bolivar", MinorUnits: digits(2), Status: Withdrawn},
+	{Code: "ZMK", Numeric: "894", Name: "Zambian Kwacha", MinorUnits: digits(2), Status: Withdrawn},
+	{Code: "ZWL", Numeric: "932", Name: "Zimbabwe Dollar", MinorUnits: digits(2), Status: Withdrawn},
+}
@@ -0,0 +1,95 @@
+package iso4217
+
+import (
+	"sort"
+	"strings"
+)
+
+// Status represents whether a currency is in use or it has been withdrawn from the standard.
+type Status string
+
+const (
+	// Active represents a currency listed in the current ISO 4217 list.
+	Active Status = Status("active")
+
+	// Withdrawn represents a currency that has been removed from the ISO 4217 list, the stored
+	// history of these currencies is still valid.
+	Withdrawn Status = Status("withdrawn")
+)
+
+// Currency represents a currency of the ISO 4217 standard.
+type Currency struct {
+	// Code represents the alphabetic code, e.g.: MXN.
+	Code string `json:"code"`
+
+	// Numeric represents the numeric code, e.g.: 484.
+	Numeric string `json:"numeric"`
+
+	// Name represents the name of the currency, e.g.: Mexican Peso.
+	Name string `json:"name"`
+
+	// MinorUnits represents the number of digits after the decimal separator, it is nil for the
+	// currencies where it is not applicable like the precious metals.
+	MinorUnits *int `json:"minor_units"`
+
+	// Status represents whether the currency is active or withdrawn.
+	Status Status `json:"status"`
+}
+
+// Digits returns the number of minor units and false when they are not applicable.
+func (c Currency) Digits() (int, bool) {
+	if c.MinorUnits == nil {
+		return 0, false
+	}
+
+	return *c.MinorUnits, true
+}
+
+// byCode indexes the registry by the alphabetic code.
+var byCode = func() map[string]Currency {
+	m := make(map[string]Currency, len(registry))
+
+	for i := range registry {
+		m[registry[i].Code] = registry[i]
+	}
+
+	return m
+}()
+
+// Lookup finds a currency by its alphabetic code, the code is case insensitive.
+func Lookup(code string) (Currency, bool) {
+	c, ok := byCode[strings.ToUpper(code)]
+
+	return c, ok
+}
+
+// List returns the currencies sorted by its code, if no status is passed all the currencies are
+// returned, otherwise only the ones with the passed statuses.
+func List(statuses ...Status) []Currency {
+	currencies := make([]Currency, 0, len(registry))
+
+	for i := range registry {
+		if len(statuses) > 0 && !hasStatus(statuses, registry[i].Status) {
+			continue
+		}
+
+		currencies = append(currencies, registry[i])
+	}
+
+	sort.Slice(currencies, func(i, j int) bool {
+		return currencies[i].Code < currencies[j].Code
+	})
+
+	return currencies
+}
+
+// hasStatus checks if the status is in the list.
+func hasStatus(statuses []Status, status Status) bool {
+	for i := range statuses {
+		if statuses[i] == status {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,91 @@
+package iso4217_test
+
+import (
+	"testing"
+
+	"github.com/PacoDw/currency/iso4217"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookup(t *testing.T) {
+	t.Run("Active", func(t *testing.T) {
+		c, ok := iso4217.Lookup("mxn")
+
+		assert.True(t, ok)
+		assert.EqualValues(t, "MXN", c.Code)
+		assert.EqualValues(t, "484", c.Numeric)
+		assert.EqualValues(t, "Mexican Peso", c.Name)
+		assert.EqualValues(t, iso4217.Active, c.Status)
+
+		d, ok := c.Digits()
+
+		assert.True(t, ok)
+		assert.EqualValues(t, 2, d)
+	})
+
+	t.Run("Withdrawn", func(t *testing.T) {
+		c, ok := iso4217.Lookup("HRK")
+
+		assert.True(t, ok)
+		assert.EqualValues(t, iso4217.Withdrawn, c.Status)
+	})
+
+	t.Run("MinorUnits", func(t *testing.T) {
+		cases := map[string]int{"JPY": 0, "USD": 2, "KWD": 3, "CLF": 4}
+
+		for code, expected := range cases {
+			c, ok := iso4217.Lookup(code)
+			assert.True(t, ok, code)
+
+			d, ok := c.Digits()
+			assert.True(t, ok, code)
+			assert.EqualValues(t, expected, d, code)
+		}
+
+		c, ok := iso4217.Lookup("XAU")
+		assert.True(t, ok)
+
+		_, ok = c.Digits()
+		assert.False(t, ok)
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		for _, code := range []string{"ZZZ", "", "US", "USDT"} {
+			_, ok := iso4217.Lookup(code)
+
+			assert.False(t, ok, code)
+		}
+	})
+}
+
+func TestList(t *testing.T) {
+	all := iso4217.List()
+	active := iso4217.List(iso4217.Active)
+	withdrawn := iso4217.List(iso4217.Withdrawn)
+
+	assert.EqualValues(t, len(all), len(active)+len(withdrawn))
+
+	codes := map[string]bool{}
+	numerics := map[string]string{}
+
+	for i := range all {
+		assert.Regexp(t, "^[A-Z]{3}$", all[i].Code)
+		assert.Regexp(t, "^[0-9]{3}$", all[i].Numeric)
+		assert.NotEmpty(t, all[i].Name)
+
+		if i > 0 {
+			assert.Less(t, all[i-1].Code, all[i].Code)
+		}
+
+		assert.False(t, codes[all[i].Code], "duplicated code %s", all[i].Code)
+		codes[all[i].Code] = true
+	}
+
+	// the numeric codes of the active currencies are unique
+	for i := range active {
+		code, ok := numerics[active[i].Numeric]
+
+		assert.False(t, ok, "%s and %s share the numeric code %s", code, active[i].Code, active[i].Numeric)
+		numerics[active[i].Numeric] = active[i].Code
+	}
+}
@@ -65,6 +65,9 @@ func NewLogger(config *Config) *Logger {
 	// Add all std logs into our logger
 	zap.RedirectStdLog(logger)
 
+	// Use it as the global logger too, e.g.: the routes log the repository failures with it
+	zap.ReplaceGlobals(logger)
+
 	logger.Info(fmt.Sprintf("Initializing on %s Mode", config.Environment))
 
 	return &Logger{
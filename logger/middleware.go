@@ -9,6 +9,14 @@ import (
 	"go.uber.org/zap"
 )
 
+// skipPaths are the health check and metrics routes which are called too often to be logged.
+var skipPaths = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+	"/status":  true,
+	"/metrics": true,
+}
+
 // ChiZapLoggerMiddleware is a middleware that logs the start and end of each request, along
 // with some useful data about what was requested, what the response status was,
 // and how long it took to return.
@@ -16,7 +24,7 @@ func ChiZapLoggerMiddleware(l *Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
 			// avoiding print the healtcheck logs
-			if r.RequestURI == "/status" {
+			if skipPaths[r.URL.Path] {
 				next.ServeHTTP(w, r)
 
 				return
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"log"
 	"os"
+	"strings"
 
 	"github.com/PacoDw/currency/providers"
 	"github.com/PacoDw/currency/repository"
@@ -20,23 +22,61 @@ func init() {
 	serverPort = os.Getenv("SERVER_PORT")
 }
 
+// newCurrencyProvider creates the Currency Provider from the env variables, the European Central Bank
+// reference rates are used as fallback only when its url is set and they are converted to the base
+// currency of the Free Currency Api Provider.
+func newCurrencyProvider() providers.Currencier {
+	currencyProvider := providers.NewFreeCurrencyAPI(providers.DefaultFreeCurrencyAPIConfig())
+	if os.Getenv("ECB_URL") != "" {
+		currencyProvider = providers.NewFallbackProvider(currencyProvider, providers.NewECB(providers.DefaultECBConfig()))
+	}
+
+	return currencyProvider
+}
+
 func main() {
 	// start connection with postgres
 	repo := repository.NewSQLConnection(repository.DefaultPostgresConfig())
 
+	// run the command passed as argument instead of the server, e.g.: migrate up
+	if len(os.Args) > 1 {
+		if err := runCommand(repo, os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
+	// the server refuses to start when the database schema is behind the binary
+	if err := repo.CheckSchemaVersion(); err != nil {
+		log.Fatal(err)
+	}
+
+	currencyProvider := newCurrencyProvider()
+
 	// create server and pass a configuration
 	s := server.New(
 		// pass repository with postgres connection
-		server.Repository(repo),
+		server.Repository(repo.RequestStatus, repo.CurrencyValue),
+
+		// the database is checked by the readiness and status routes
+		server.Database(repo),
+
+		// only the replica holding the advisory lock makes the requests to the currency provider
+		server.LeaderElection(repo),
 
 		// setting the currency provider into the server to make the proper requests
-		server.CurrencyProvider(
-			providers.NewFreeCurrencyAPI(providers.DefaultFreeCurrencyAPIConfig()),
-		),
+		server.CurrencyProvider(currencyProvider),
 
 		// setting the request interval for the currency provider
 		server.CurrencyRequestInterval(os.Getenv("REQUEST_INTERVAL")),
 
+		// the cron expressions separated by semicolons take precedence over the request interval
+		server.CronSchedule(strings.Split(os.Getenv("REQUEST_SCHEDULE"), ";")...),
+
+		// the values older than the retention are rolled up into hourly and daily aggregates
+		server.Retention(server.DefaultEnvRetentionPolicy()),
+
 		// setting some default middlewares to handle the server
 		server.UseMidlewares(
 			middleware.StripSlashes, // match paths with a trailing slash, strip it, and continue routing through the mux
@@ -56,11 +96,52 @@ func main() {
 			}),
 		)
 
+		// the ISO 4217 currencies known by the service
+		r.Get("/", routes.ListCurrenciesRoute())
+
 		// creating the sub route passing a middleware to handle the currency route parameter and then
-		// the route controller.
+		// the route controller, the values could be aggregated in time buckets.
+		r.
+			With(
+				server.ValidateRouteParametersMiddleware([]routes.RouteParameter{routes.Currency}, s.IsStoredCurrency),
+				server.ValidateBucketQueryParameterMiddleware(routes.Bucket),
+			).
+			Get("/{currency}", routes.CurrencyRoute(repo.CurrencyValue))
+
+		// the latest value of the currency, or of each currency using 'all'
+		r.
+			With(server.ValidateRouteParametersMiddleware([]routes.RouteParameter{routes.Currency}, s.IsStoredCurrency)).
+			Get("/{currency}/latest", routes.LatestCurrencyRoute(repo.CurrencyValue))
+
+		// the windows without requests compared against the job schedule with the failed requests
+		r.
+			With(server.ValidateRouteParametersMiddleware([]routes.RouteParameter{routes.Currency}, s.IsStoredCurrency)).
+			Get("/{currency}/gaps", routes.CurrencyGapsRoute(repo.CurrencyValue, s.ExpectedSchedule()))
+	})
+
+	// converting an amount between two currencies using the stored rates, the middlewares check
+	// the currencies, the amount and the optional snapshot date passed as query parameters.
+	s.
+		With(
+			server.ValidateDateTimeQueryParametersMiddleware([]routes.DateTimeQueryParameter{routes.AsOf}),
+			server.ValidateCurrencyQueryParametersMiddleware([]routes.CurrencyQueryParameter{routes.From, routes.To}, s.IsStoredCurrency),
+			server.ValidateAmountQueryParameterMiddleware(routes.Amount),
+		).
+		Get("/convert", routes.ConvertRoute(repo.CurrencyValue))
+
+	// the history of the requests made to the Currency Provider, a request is returned with the
+	// currency values captured by it.
+	s.Route("/requests", func(r chi.Router) {
+		r.
+			With(
+				server.ValidateDateTimeQueryParametersMiddleware([]routes.DateTimeQueryParameter{routes.Finit, routes.Fend}),
+				server.ValidatePaginationQueryParametersMiddleware([]routes.PaginationQueryParameter{routes.Limit, routes.Offset}),
+			).
+			Get("/", routes.ListRequestsStatusRoute(repo.RequestStatus))
+
 		r.
-			With(server.ValidateRouteParametersMiddleware([]routes.RouteParameter{routes.Currency})).
-			Get("/{currency}", routes.CurrencyRoute(repo))
+			With(server.ValidateIDRouteParameterMiddleware(routes.ID)).
+			Get("/{id}", routes.RequestStatusRoute(repo.RequestStatus, repo.CurrencyValue))
 	})
 
 	// start the server
@@ -0,0 +1,198 @@
+package metrics
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace is the prefix of all the metrics exposed by the service.
+const namespace = "currency"
+
+// unmatchedRoute is the route label of the requests that don't match any route, so unknown paths
+// don't create a new serie each one.
+const unmatchedRoute = "unmatched"
+
+// Metrics represents the Prometheus collectors of the API and the Currency Provider job, each Metrics
+// has its own registry so it could be created more than once, e.g.: in the tests.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	providerRequests        *prometheus.CounterVec
+	providerRequestDuration *prometheus.HistogramVec
+	bulkInsertRows          *prometheus.CounterVec
+	bulkInsertBatchSize     prometheus.Histogram
+	bulkInsertDuration      *prometheus.HistogramVec
+	httpRequests            *prometheus.CounterVec
+	httpRequestDuration     *prometheus.HistogramVec
+	providerLeader          prometheus.Gauge
+
+	mu         sync.RWMutex
+	newestRate time.Time
+}
+
+// New creates the collectors and registers them with the Go runtime and process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		providerRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "provider_requests_total",
+			Help:      "Number of requests made to the Currency Provider by provider and status.",
+		}, []string{"provider", "status"}),
+		providerRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "provider_request_duration_seconds",
+			Help:      "Duration of the requests made to the Currency Provider by provider and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"provider", "status"}),
+		bulkInsertRows: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "bulk_insert_rows_total",
+			Help:      "Number of currency values passed to BulkInsert by status: success, skipped or failure.",
+		}, []string{"status"}),
+		bulkInsertBatchSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "bulk_insert_batch_size",
+			Help:      "Number of currency values passed to each BulkInsert.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 10),
+		}),
+		bulkInsertDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "bulk_insert_duration_seconds",
+			Help:      "Duration of each BulkInsert by status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"status"}),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of http requests by method, route and status code.",
+		}, []string{"method", "route", "code"}),
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of the http requests by method and route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		providerLeader: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "provider_leader",
+			Help:      "Whether this replica is the one making the requests to the Currency Provider.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.providerRequests,
+		m.providerRequestDuration,
+		m.bulkInsertRows,
+		m.bulkInsertBatchSize,
+		m.bulkInsertDuration,
+		m.httpRequests,
+		m.httpRequestDuration,
+		m.providerLeader,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "newest_rate_age_seconds",
+			Help:      "Age of the newest stored rate, it is NaN while there is no rate stored.",
+		}, m.newestRateAge),
+	)
+
+	return m
+}
+
+// Handler returns the handler exposing the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware counts the http requests by the chi route pattern instead of the path, so the route
+// parameters like the currency don't create a new serie each one.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		route := unmatchedRoute
+
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+
+		// the status is 0 when the handler does not write anything
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		m.httpRequests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+		m.httpRequestDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// ObserveProviderRequest registers a request made to the Currency Provider.
+func (m *Metrics) ObserveProviderRequest(provider, status string, elapsed time.Duration) {
+	m.providerRequests.WithLabelValues(provider, status).Inc()
+	m.providerRequestDuration.WithLabelValues(provider, status).Observe(elapsed.Seconds())
+}
+
+// ObserveBulkInsert registers a BulkInsert of rows currency values, the skipped ones were already
+// stored. When it fails all the values are registered as failed.
+func (m *Metrics) ObserveBulkInsert(rows, skipped int, elapsed time.Duration, err error) {
+	if err != nil {
+		m.bulkInsertRows.WithLabelValues("failure").Add(float64(rows))
+		m.bulkInsertDuration.WithLabelValues("failure").Observe(elapsed.Seconds())
+	} else {
+		m.bulkInsertRows.WithLabelValues("success").Add(float64(rows - skipped))
+		m.bulkInsertRows.WithLabelValues("skipped").Add(float64(skipped))
+		m.bulkInsertDuration.WithLabelValues("success").Observe(elapsed.Seconds())
+	}
+
+	m.bulkInsertBatchSize.Observe(float64(rows))
+}
+
+// SetLeader registers whether this replica is the leader of the Currency Provider job.
+func (m *Metrics) SetLeader(leader bool) {
+	if leader {
+		m.providerLeader.Set(1)
+
+		return
+	}
+
+	m.providerLeader.Set(0)
+}
+
+// SetNewestRate registers the time of a stored rate, an older time than the current one is ignored.
+func (m *Metrics) SetNewestRate(t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if t.After(m.newestRate) {
+		m.newestRate = t
+	}
+}
+
+// newestRateAge returns the seconds since the newest stored rate.
+func (m *Metrics) newestRateAge() float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.newestRate.IsZero() {
+		return math.NaN()
+	}
+
+	return time.Since(m.newestRate).Seconds()
+}
@@ -0,0 +1,80 @@
+package metrics_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PacoDw/currency/metrics"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// scrape returns the metrics in the Prometheus text format.
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+
+	blob, err := io.ReadAll(w.Body)
+	assert.Nil(t, err)
+
+	return string(blob)
+}
+
+func TestMetrics(t *testing.T) {
+	m := metrics.New()
+
+	t.Run("newest rate age is unknown", func(t *testing.T) {
+		assert.Contains(t, scrape(t, m), "currency_newest_rate_age_seconds NaN")
+	})
+
+	t.Run("provider and bulk insert", func(t *testing.T) {
+		m.ObserveProviderRequest("currencyapi", "success", 150*time.Millisecond)
+		m.ObserveProviderRequest("currencyapi", "failure", time.Second)
+		m.ObserveProviderRequest("currencyapi", "success", 250*time.Millisecond)
+		m.ObserveBulkInsert(170, 0, 20*time.Millisecond, nil)
+		m.ObserveBulkInsert(3, 0, 20*time.Millisecond, errors.New("failed"))
+		m.ObserveBulkInsert(170, 168, 20*time.Millisecond, nil)
+		m.SetNewestRate(time.Now().Add(-time.Hour))
+		m.SetNewestRate(time.Now().Add(-48 * time.Hour))
+		m.SetLeader(true)
+
+		out := scrape(t, m)
+
+		assert.Contains(t, out, `currency_provider_requests_total{provider="currencyapi",status="success"} 2`)
+		assert.Contains(t, out, `currency_provider_requests_total{provider="currencyapi",status="failure"} 1`)
+		assert.Contains(t, out, `currency_provider_request_duration_seconds_count{provider="currencyapi",status="success"} 2`)
+		assert.Contains(t, out, `currency_bulk_insert_rows_total{status="success"} 172`)
+		assert.Contains(t, out, `currency_bulk_insert_rows_total{status="skipped"} 168`)
+		assert.Contains(t, out, `currency_bulk_insert_rows_total{status="failure"} 3`)
+		assert.Contains(t, out, `currency_bulk_insert_batch_size_count 3`)
+		assert.Contains(t, out, `currency_bulk_insert_duration_seconds_count{status="success"} 2`)
+		assert.Regexp(t, `currency_newest_rate_age_seconds 36\d\d`, out)
+		assert.Contains(t, out, "currency_provider_leader 1")
+	})
+
+	t.Run("http requests by route", func(t *testing.T) {
+		r := chi.NewRouter()
+		r.Use(m.Middleware)
+		r.Get("/currencies/{currency}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		})
+
+		for _, path := range []string{"/currencies/mxn", "/currencies/usd", "/unknown"} {
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, http.NoBody))
+		}
+
+		out := scrape(t, m)
+
+		assert.Contains(t, out, `currency_http_requests_total{code="400",method="GET",route="/currencies/{currency}"} 2`)
+		assert.Contains(t, out, `currency_http_requests_total{code="404",method="GET",route="unmatched"} 1`)
+		assert.Contains(t, out, `currency_http_request_duration_seconds_count{method="GET",route="/currencies/{currency}"} 2`)
+	})
+}
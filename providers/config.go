@@ -21,6 +21,14 @@ type CurrencyConfig struct {
 	// this is a required attribute.
 	Timeout time.Duration
 
+	// BaseCurrency is an optional attribute which represents the currency that the rates are
+	// relative to, if it is empty the base currency of the Currency Provider is used.
+	BaseCurrency string
+
+	// Retry is an optional attribute which represents how the failed requests are tried again, if
+	// it is empty only one attempt is made.
+	Retry RetryPolicy
+
 	// Logger is an optional attribute, plz use checkLogger to set a default logger
 	Logger *logger.Logger
 }
@@ -53,6 +61,12 @@ func (cfg *CurrencyConfig) Valid() error {
 		return errors.New("the APIKey attribute must not be empty")
 	}
 
+	return cfg.validRequest()
+}
+
+// validRequest validates the attributes needed to make a request, it is used by the Currency
+// Providers which don't need an APIKey.
+func (cfg *CurrencyConfig) validRequest() error {
 	if cfg.URL == nil {
 		return errors.New("the URL attribute must not be empty")
 	}
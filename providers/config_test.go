@@ -61,6 +61,7 @@ func TestCurrencyConfig(t *testing.T) {
 			URL:     u,
 			APIKey:  "API_KEY",
 			Timeout: time.Duration(3 * time.Second),
+			Retry:   providers.DefaultRetryPolicy(),
 		}
 
 		got := providers.DefaultFreeCurrencyAPIConfig()
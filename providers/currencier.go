@@ -5,13 +5,18 @@ import (
 	"time"
 
 	"github.com/PacoDw/currency/logger"
+	"github.com/pkg/errors"
 )
 
+// ErrHistoricalNotSupported is returned when the Currency Provider can't retrieve the exchange rates of
+// a past day.
+var ErrHistoricalNotSupported = errors.New("the currency provider does not support historical exchange rates")
+
 // Currencier represent the interface for Currency Providers.
 type Currencier interface {
 	// GetLatestExchangeRates should retrieve the latest exhange rates from
-	// Currency Provider.
-	GetLatestExchangeRates(ctx context.Context) (*Metadata, []byte, error)
+	// Currency Provider, the rates must be validated before returning them.
+	GetLatestExchangeRates(ctx context.Context) (*Metadata, []Rate, error)
 
 	// SetLogger represenst an easy way to set an specific logger.
 	SetLogger(l *logger.Logger)
@@ -19,3 +24,13 @@ type Currencier interface {
 	// GetTimeoutRequest helps to check the current Timeout of the request.
 	GetTimeoutRequest() time.Duration
 }
+
+// HistoricalCurrencier represents the Currency Providers that can retrieve the exchange rates of a past
+// day, e.g.: to fill the history of a fresh database.
+type HistoricalCurrencier interface {
+	Currencier
+
+	// GetHistoricalExchangeRates should retrieve the exchange rates at the end of the day of the date
+	// from the Currency Provider, the rates must be validated before returning them.
+	GetHistoricalExchangeRates(ctx context.Context, date time.Time) (*Metadata, []Rate, error)
+}
@@ -0,0 +1,212 @@
+package providers
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// ECBName is the name registered in the Metadata by the European Central Bank Provider.
+const ECBName = "ecb"
+
+// ecbBaseCurrency is the currency which the ECB reference rates are relative to.
+const ecbBaseCurrency = "EUR"
+
+// ecb is a Currency Provider that reads the reference rates published by the European Central Bank.
+type ecb struct {
+	*CurrencyConfig
+
+	client *http.Client
+}
+
+// ecbEnvelope represents the eurofxref XML document.
+type ecbEnvelope struct {
+	XMLName xml.Name `xml:"Envelope"`
+	Cubes   []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string          `xml:"currency,attr"`
+			Rate     decimal.Decimal `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// GetLatestExchangeRates returns the stats as metadata, the validated ECB reference rates, and finally
+// an error if the case.
+func (e *ecb) GetLatestExchangeRates(ctx context.Context) (*Metadata, []Rate, error) {
+	// creates the metadata struct
+	meta := &Metadata{
+		Provider:    ECBName,
+		URL:         e.URL.String(),
+		RequestedAt: time.Now(),
+		Status:      "failure",
+	}
+
+	// when the process finishs we will register the elapsed time
+	defer func() { meta.Elapsed = time.Since(meta.RequestedAt).String() }()
+
+	// set the timeout of each request
+	ctx, cancel := context.WithTimeout(ctx, e.Timeout)
+	defer cancel()
+
+	rates, err := e.fetch(ctx, meta)
+	if err != nil {
+		if ctx.Err() != nil {
+			err = errors.Wrap(ctx.Err(), "the proccess ends before finish by timeout")
+		}
+
+		meta.Error = err
+
+		return meta, nil, err
+	}
+
+	meta.Status = "success"
+
+	return meta, rates, nil
+}
+
+// fetch makes the request to the ECB and normalises the XML document, the status code and the size of
+// the response are registered in the metadata.
+func (e *ecb) fetch(ctx context.Context, meta *Metadata) ([]Rate, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.URL.String(), http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	meta.StatusCode = res.StatusCode
+
+	blob, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	meta.ResponseBytes = int64(len(blob))
+
+	if err := checkStatusCode(res.StatusCode, blob); err != nil {
+		return nil, err
+	}
+
+	var env ecbEnvelope
+	if err := xml.Unmarshal(blob, &env); err != nil {
+		return nil, errors.Wrap(err, "failed to decode the ECB document")
+	}
+
+	return e.normalise(&env)
+}
+
+// normalise converts the latest day of the ECB document into rates, if the BaseCurrency is set the
+// rates are converted to be relative to it.
+// Note: the conversion divides the rates so it is rounded to decimal.DivisionPrecision digits.
+func (e *ecb) normalise(env *ecbEnvelope) ([]Rate, error) {
+	if len(env.Cubes) == 0 {
+		return nil, errors.New("the ECB document has no rates")
+	}
+
+	// the first cube is the most recent day
+	cube := env.Cubes[0]
+
+	day, err := time.Parse("2006-01-02", cube.Time)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the time of the ECB document")
+	}
+
+	rates := map[string]decimal.Decimal{ecbBaseCurrency: decimal.NewFromInt(1)}
+
+	for i := range cube.Rates {
+		rates[strings.ToUpper(cube.Rates[i].Currency)] = cube.Rates[i].Rate
+	}
+
+	base := decimal.NewFromInt(1)
+
+	if e.BaseCurrency != "" {
+		var ok bool
+
+		base, ok = rates[strings.ToUpper(e.BaseCurrency)]
+		if !ok || base.IsZero() {
+			return nil, errors.Errorf("the ECB document has no rate for the base currency (%s)", e.BaseCurrency)
+		}
+	}
+
+	normalised := make([]Rate, 0, len(rates))
+
+	for code, rate := range rates {
+		normalised = append(normalised, Rate{
+			Code:          code,
+			Value:         rate.Div(base),
+			LastUpdatedAt: day.UTC(),
+		})
+	}
+
+	if err := validRates(normalised); err != nil {
+		return nil, err
+	}
+
+	return normalised, nil
+}
+
+// GetTimeoutRequest returns the timeout set for requests.
+func (e *ecb) GetTimeoutRequest() time.Duration {
+	return e.Timeout
+}
+
+// NewECB creates a new European Central Bank Provider, the APIKey is not needed.
+func NewECB(cfg *CurrencyConfig) Currencier {
+	if cfg == nil {
+		panic("the CurrencyConfig must not be nil")
+	}
+
+	if err := cfg.validRequest(); err != nil {
+		panic(err)
+	}
+
+	return &ecb{
+		CurrencyConfig: cfg,
+		client:         &http.Client{},
+	}
+}
+
+// DefaultECBConfig sets the default configuration taking the proper env variables, ECB_URL is required,
+// e.g.: https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml. The ECB is the fallback of the
+// Free Currency Api Provider, so if ECB_BASE_CURRENCY is not set the rates are converted to its base
+// currency and the stored series don't jump when the fallback is used.
+func DefaultECBConfig() *CurrencyConfig {
+	rawURL := os.Getenv("ECB_URL")
+	if rawURL == "" {
+		panic(errors.New("the ECB_URL env variable must be set"))
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		panic(err)
+	}
+
+	baseCurrency := os.Getenv("ECB_BASE_CURRENCY")
+	if baseCurrency == "" {
+		baseCurrency = FreeCurrencyAPIBaseCurrency
+	}
+
+	reqTimeout, err := time.ParseDuration(os.Getenv("REQUEST_TIMEOUT"))
+	if err != nil {
+		panic(err)
+	}
+
+	return &CurrencyConfig{
+		URL:          u,
+		Timeout:      reqTimeout,
+		BaseCurrency: baseCurrency,
+	}
+}
@@ -0,0 +1,124 @@
+package providers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/PacoDw/currency/providers"
+	"github.com/stretchr/testify/assert"
+)
+
+// ratesByCode indexes the rates by its code.
+func ratesByCode(rates []providers.Rate) map[string]providers.Rate {
+	m := make(map[string]providers.Rate, len(rates))
+
+	for i := range rates {
+		m[rates[i].Code] = rates[i]
+	}
+
+	return m
+}
+
+// newECBServer creates a server that serves the recorded ECB document.
+func newECBServer(t *testing.T) (*httptest.Server, *url.URL) {
+	t.Helper()
+
+	blob, err := os.ReadFile("testdata/eurofxref-daily.xml")
+	assert.Nil(t, err)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write(blob)
+	}))
+
+	u, err := url.Parse(ts.URL)
+	assert.Nil(t, err)
+
+	return ts, u
+}
+
+func TestECB(t *testing.T) {
+	ts, u := newECBServer(t)
+	defer ts.Close()
+
+	t.Run("EUR as base currency", func(t *testing.T) {
+		p := providers.NewECB(&providers.CurrencyConfig{URL: u, Timeout: time.Second})
+
+		meta, rates, err := p.GetLatestExchangeRates(context.Background())
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, providers.ECBName, meta.Provider)
+		assert.EqualValues(t, "success", meta.Status)
+		assert.Len(t, rates, 31)
+
+		byCode := ratesByCode(rates)
+
+		assert.EqualValues(t, time.Date(2022, 10, 18, 0, 0, 0, 0, time.UTC), byCode["MXN"].LastUpdatedAt)
+		assert.EqualValues(t, "1", byCode["EUR"].Value.String())
+		assert.EqualValues(t, "19.6942", byCode["MXN"].Value.String())
+	})
+
+	t.Run("USD as base currency", func(t *testing.T) {
+		p := providers.NewECB(&providers.CurrencyConfig{URL: u, Timeout: time.Second, BaseCurrency: "USD"})
+
+		_, rates, err := p.GetLatestExchangeRates(context.Background())
+
+		assert.Nil(t, err)
+
+		byCode := ratesByCode(rates)
+
+		assert.EqualValues(t, "1", byCode["USD"].Value.String())
+		assert.InDelta(t, 1/0.9835, byCode["EUR"].Value.InexactFloat64(), 1e-9)
+		assert.InDelta(t, 19.6942/0.9835, byCode["MXN"].Value.InexactFloat64(), 1e-9)
+	})
+
+	t.Run("unknown base currency", func(t *testing.T) {
+		p := providers.NewECB(&providers.CurrencyConfig{URL: u, Timeout: time.Second, BaseCurrency: "VND"})
+
+		meta, rates, err := p.GetLatestExchangeRates(context.Background())
+
+		assert.EqualError(t, err, "the ECB document has no rate for the base currency (VND)")
+		assert.EqualValues(t, "failure", meta.Status)
+		assert.Nil(t, rates)
+	})
+
+	t.Run("unexpected status code", func(t *testing.T) {
+		fail := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer fail.Close()
+
+		fu, _ := url.Parse(fail.URL)
+
+		meta, _, err := providers.NewECB(&providers.CurrencyConfig{URL: fu, Timeout: time.Second}).
+			GetLatestExchangeRates(context.Background())
+
+		assert.EqualError(t, err, "unexpected status code 503")
+		assert.EqualValues(t, http.StatusServiceUnavailable, meta.StatusCode)
+		assert.EqualValues(t, "unexpected status code 503", meta.ErrorMessage())
+	})
+
+	t.Run("default config", func(t *testing.T) {
+		t.Setenv("ECB_URL", "")
+
+		assert.Panics(t, func() { providers.DefaultECBConfig() })
+
+		t.Setenv("ECB_URL", "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml")
+
+		cfg := providers.DefaultECBConfig()
+
+		assert.EqualValues(t, "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml", cfg.URL.String())
+		assert.EqualValues(t, 3*time.Second, cfg.Timeout)
+		assert.EqualValues(t, providers.FreeCurrencyAPIBaseCurrency, cfg.BaseCurrency, "the rates have the base of the primary provider")
+		assert.NotPanics(t, func() { providers.NewECB(cfg) })
+
+		t.Setenv("ECB_BASE_CURRENCY", "EUR")
+
+		assert.EqualValues(t, "EUR", providers.DefaultECBConfig().BaseCurrency)
+	})
+}
@@ -0,0 +1,136 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PacoDw/currency/logger"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// fallbackProvider is a Currency Provider that wraps an ordered list of Currency Providers,
+// the first one that answers successfully is the one used.
+type fallbackProvider struct {
+	providers []Currencier
+	logger    *logger.Logger
+}
+
+// fallbackProvider validates if it satisfies the historical interface.
+var _ HistoricalCurrencier = &fallbackProvider{}
+
+// GetLatestExchangeRates tries each Currency Provider in order and returns the result of the first one
+// that answers, the Provider attribute of the Metadata tells which one was. If one of them fails or
+// reaches its timeout the next one is tried, if all of them fail the metadata of the last one is
+// returned with an error that contains all the errors.
+func (fp *fallbackProvider) GetLatestExchangeRates(ctx context.Context) (*Metadata, []Rate, error) {
+	return fp.try(ctx, fp.providers, func(p Currencier) (*Metadata, []Rate, error) {
+		return p.GetLatestExchangeRates(ctx)
+	})
+}
+
+// GetHistoricalExchangeRates tries in order the Currency Providers that support the historical exchange
+// rates like GetLatestExchangeRates does, if none of them supports it ErrHistoricalNotSupported is
+// returned.
+func (fp *fallbackProvider) GetHistoricalExchangeRates(ctx context.Context, date time.Time) (*Metadata, []Rate, error) {
+	historical := make([]Currencier, 0, len(fp.providers))
+
+	for i := range fp.providers {
+		if _, ok := fp.providers[i].(HistoricalCurrencier); ok {
+			historical = append(historical, fp.providers[i])
+		}
+	}
+
+	if len(historical) == 0 {
+		return &Metadata{RequestedAt: time.Now(), Status: "failure", Error: ErrHistoricalNotSupported}, nil, ErrHistoricalNotSupported
+	}
+
+	return fp.try(ctx, historical, func(p Currencier) (*Metadata, []Rate, error) {
+		return p.(HistoricalCurrencier).GetHistoricalExchangeRates(ctx, date)
+	})
+}
+
+// try calls fn with each Currency Provider in order until one of them answers successfully.
+func (fp *fallbackProvider) try(ctx context.Context, ps []Currencier, fn func(Currencier) (*Metadata, []Rate, error)) (*Metadata, []Rate, error) {
+	var (
+		meta *Metadata
+		errs = make([]string, 0, len(ps))
+	)
+
+	for i := range ps {
+		// the parent context is done so there is no reason to try the next one
+		if ctx.Err() != nil {
+			break
+		}
+
+		m, rates, err := fn(ps[i])
+		if err == nil {
+			return m, rates, nil
+		}
+
+		if m == nil {
+			m = &Metadata{RequestedAt: time.Now()}
+		}
+
+		meta = m
+		errs = append(errs, fmt.Sprintf("%s: %s", m.Provider, err))
+
+		if fp.logger != nil && i < len(ps)-1 {
+			fp.logger.Warn("Currency Provider failed, trying the next one",
+				zap.String("provider", m.Provider),
+				zap.String("err", err.Error()),
+			)
+		}
+	}
+
+	if meta == nil {
+		meta = &Metadata{RequestedAt: time.Now(), Elapsed: time.Duration(0).String()}
+		errs = append(errs, errors.Wrap(ctx.Err(), "the proccess ends before trying any provider").Error())
+	}
+
+	meta.Status = "failure"
+	meta.Error = errors.Errorf("all the currency providers failed: %s", strings.Join(errs, "; "))
+
+	return meta, nil, meta.Error
+}
+
+// SetLogger sets the logger in the fallback provider and in all the wrapped providers.
+func (fp *fallbackProvider) SetLogger(l *logger.Logger) {
+	fp.logger = l
+
+	for i := range fp.providers {
+		fp.providers[i].SetLogger(l)
+	}
+}
+
+// GetTimeoutRequest returns the sum of the timeouts of all the wrapped providers, which is the
+// longest time that a request could take.
+func (fp *fallbackProvider) GetTimeoutRequest() time.Duration {
+	var timeout time.Duration
+
+	for i := range fp.providers {
+		timeout += fp.providers[i].GetTimeoutRequest()
+	}
+
+	return timeout
+}
+
+// NewFallbackProvider creates a new Currency Provider that tries the passed providers in order until
+// one of them answers successfully.
+func NewFallbackProvider(providers ...Currencier) Currencier {
+	if len(providers) == 0 {
+		panic("the fallback provider needs at least one Currency Provider")
+	}
+
+	for i := range providers {
+		if providers[i] == nil {
+			panic("the Currency Providers must not be nil")
+		}
+	}
+
+	return &fallbackProvider{
+		providers: providers,
+	}
+}
@@ -0,0 +1,131 @@
+package providers_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/PacoDw/currency/logger"
+	"github.com/PacoDw/currency/providers"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubProvider is a Currency Provider that answers with a fixed result.
+type stubProvider struct {
+	name    string
+	rates   []providers.Rate
+	err     error
+	delay   time.Duration
+	timeout time.Duration
+	calls   int
+}
+
+func (sp *stubProvider) GetLatestExchangeRates(ctx context.Context) (*providers.Metadata, []providers.Rate, error) {
+	sp.calls++
+
+	meta := &providers.Metadata{Provider: sp.name, RequestedAt: time.Now()}
+
+	ctx, cancel := context.WithTimeout(ctx, sp.timeout)
+	defer cancel()
+
+	select {
+	case <-ctx.Done():
+		meta.Status = "failure"
+
+		return meta, nil, ctx.Err()
+	case <-time.After(sp.delay):
+	}
+
+	if sp.err != nil {
+		meta.Status = "failure"
+
+		return meta, nil, sp.err
+	}
+
+	meta.Status = "success"
+
+	return meta, sp.rates, nil
+}
+
+func (sp *stubProvider) SetLogger(l *logger.Logger) {}
+
+func (sp *stubProvider) GetTimeoutRequest() time.Duration { return sp.timeout }
+
+func TestFallbackProvider(t *testing.T) {
+	t.Run("first provider answers", func(t *testing.T) {
+		first := &stubProvider{name: "first", rates: []providers.Rate{{Code: "first"}}, timeout: time.Second}
+		second := &stubProvider{name: "second", rates: []providers.Rate{{Code: "second"}}, timeout: time.Second}
+
+		meta, rates, err := providers.NewFallbackProvider(first, second).GetLatestExchangeRates(context.Background())
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "first", meta.Provider)
+		assert.EqualValues(t, "first", rates[0].Code)
+		assert.EqualValues(t, 0, second.calls)
+	})
+
+	t.Run("falls through on error and timeout", func(t *testing.T) {
+		failing := &stubProvider{name: "failing", err: errors.New("boom"), timeout: time.Second}
+		slow := &stubProvider{name: "slow", delay: time.Second, timeout: 10 * time.Millisecond}
+		last := &stubProvider{name: "last", rates: []providers.Rate{{Code: "last"}}, timeout: time.Second}
+
+		meta, rates, err := providers.NewFallbackProvider(failing, slow, last).GetLatestExchangeRates(context.Background())
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "last", meta.Provider)
+		assert.EqualValues(t, "success", meta.Status)
+		assert.EqualValues(t, "last", rates[0].Code)
+	})
+
+	t.Run("all providers fail", func(t *testing.T) {
+		first := &stubProvider{name: "first", err: errors.New("boom"), timeout: time.Second}
+		second := &stubProvider{name: "second", err: errors.New("bang"), timeout: time.Second}
+
+		meta, rates, err := providers.NewFallbackProvider(first, second).GetLatestExchangeRates(context.Background())
+
+		assert.EqualError(t, err, "all the currency providers failed: first: boom; second: bang")
+		assert.EqualValues(t, "failure", meta.Status)
+		assert.Nil(t, rates)
+	})
+
+	t.Run("timeout is the sum of the providers", func(t *testing.T) {
+		fp := providers.NewFallbackProvider(
+			&stubProvider{timeout: time.Second},
+			&stubProvider{timeout: 2 * time.Second},
+		)
+
+		assert.EqualValues(t, 3*time.Second, fp.GetTimeoutRequest())
+	})
+
+	t.Run("without providers", func(t *testing.T) {
+		assert.Panics(t, func() { providers.NewFallbackProvider() })
+	})
+}
+
+func TestFallbackProviderHistorical(t *testing.T) {
+	date := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("not supported", func(t *testing.T) {
+		fp := providers.NewFallbackProvider(&stubProvider{name: "first", timeout: time.Second}).(providers.HistoricalCurrencier)
+
+		_, _, err := fp.GetHistoricalExchangeRates(context.Background(), date)
+
+		assert.ErrorIs(t, err, providers.ErrHistoricalNotSupported)
+	})
+
+	t.Run("skips the providers without history", func(t *testing.T) {
+		first := &stubProvider{name: "first", timeout: time.Second}
+		historical, closeFn := newFreeCurrencyAPI(t, `{"meta": {"last_updated_at": "2022-01-01T23:59:59Z"}, "data": {"USD": {"code": "USD", "value": 1}}}`)
+		defer closeFn()
+
+		fp := providers.NewFallbackProvider(first, historical).(providers.HistoricalCurrencier)
+
+		meta, rates, err := fp.GetHistoricalExchangeRates(context.Background(), date)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, providers.FreeCurrencyAPIName, meta.Provider)
+		assert.Len(t, rates, 1)
+		assert.EqualValues(t, 0, first.calls)
+	})
+}
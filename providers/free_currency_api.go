@@ -2,15 +2,25 @@ package providers
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 )
 
+// FreeCurrencyAPIName is the name registered in the Metadata by the Free Currency Api Provider.
+const FreeCurrencyAPIName = "currencyapi"
+
+// FreeCurrencyAPIBaseCurrency is the currency which the rates of the Free Currency Api Provider are
+// relative to.
+const FreeCurrencyAPIBaseCurrency = "USD"
+
 // freeCurrencyApi is a Currency Provider.
 type freeCurrencyApi struct {
 	*CurrencyConfig
@@ -21,40 +31,172 @@ type freeCurrencyApi struct {
 
 // Metadata helps to register all stats about the request.
 type Metadata struct {
+	Provider    string
 	Elapsed     string
 	URL         string
 	Status      string
 	RequestedAt time.Time
 	Error       error
+
+	// Attempts represents the number of requests made, it is greater than 1 when the request
+	// was tried again.
+	Attempts int
+
+	// StatusCode represents the http status code of the last response, it is 0 when there was
+	// no response, e.g.: a timeout.
+	StatusCode int
+
+	// ResponseBytes represents the size of the body of the last response.
+	ResponseBytes int64
+
+	// Quota represents the monthly quota left after the last response, it is nil when the Currency
+	// Provider does not report it.
+	Quota *Quota
+}
+
+// ErrorMessage returns the message of the error or an empty string if there is no error.
+func (meta *Metadata) ErrorMessage() string {
+	if meta.Error == nil {
+		return ""
+	}
+
+	return meta.Error.Error()
+}
+
+// freeCurrencyAPIResponse represents the payload of the latest exchange rates.
+type freeCurrencyAPIResponse struct {
+	Meta struct {
+		LastUpdatedAt time.Time `json:"last_updated_at"`
+	} `json:"meta"`
+	Data map[string]struct {
+		Code  string          `json:"code"`
+		Value decimal.Decimal `json:"value"`
+	} `json:"data"`
+}
+
+// rates converts the payload into validated rates.
+func (res *freeCurrencyAPIResponse) rates() ([]Rate, error) {
+	rates := make([]Rate, 0, len(res.Data))
+
+	for code, val := range res.Data {
+		if val.Code != code {
+			return nil, errors.Errorf("the rate code (%s) does not match its key (%s)", val.Code, code)
+		}
+
+		rates = append(rates, Rate{
+			Code:          val.Code,
+			Value:         val.Value,
+			LastUpdatedAt: res.Meta.LastUpdatedAt,
+		})
+	}
+
+	if err := validRates(rates); err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// freeCurrencyApi validates if it satisfies the historical interface.
+var _ HistoricalCurrencier = &freeCurrencyApi{}
+
+// GetLatestExchangeRates returns the stats as metadata, the validated rates of the request, and
+// finally an error if the case. A failed request is tried again according to the RetryPolicy while
+// the context allows it.
+func (fc *freeCurrencyApi) GetLatestExchangeRates(ctx context.Context) (*Metadata, []Rate, error) {
+	return fc.exchangeRates(ctx, fc.URL, "latest")
 }
 
-// GetLatestExchangeRates returns the stats as metadata, the bytes represents the result of the request, and
-// finally an error if the case.
-func (fc *freeCurrencyApi) GetLatestExchangeRates(ctx context.Context) (*Metadata, []byte, error) {
-	var (
-		cancel context.CancelFunc
-		resCh  = make(chan []byte)
-		errCh  = make(chan error)
-	)
+// GetHistoricalExchangeRates returns the same as GetLatestExchangeRates but the rates are the ones at
+// the end of the day of the date in UTC. The historical endpoint is next to the latest one, e.g.:
+// /v3/historical?date=2022-10-18 for /v3/latest.
+func (fc *freeCurrencyApi) GetHistoricalExchangeRates(ctx context.Context, date time.Time) (*Metadata, []Rate, error) {
+	u := *fc.URL
+	u.Path = path.Join("/", path.Dir(u.Path), "historical")
 
+	q := u.Query()
+	q.Set("date", date.UTC().Format("2006-01-02"))
+	u.RawQuery = q.Encode()
+
+	return fc.exchangeRates(ctx, &u, "historical")
+}
+
+// exchangeRates makes the request to the url and returns the validated rates, the kind of the rates
+// is used by the error messages.
+func (fc *freeCurrencyApi) exchangeRates(ctx context.Context, u *url.URL, kind string) (*Metadata, []Rate, error) {
 	// creates the metadata struct
 	meta := &Metadata{
-		URL:         fc.URL.String(),
+		Provider:    FreeCurrencyAPIName,
+		URL:         u.String(),
 		RequestedAt: time.Now(),
+		Status:      "failure",
 	}
 
 	// when the process finishs we will register the elapsed time
 	defer func() { meta.Elapsed = time.Since(meta.RequestedAt).String() }()
 
+	var res fetchResult
+
+	attempts, err := fc.Retry.do(ctx, func(ctx context.Context) (bool, error) {
+		res = fc.fetch(ctx, u)
+
+		return res.retry, res.err
+	})
+
+	meta.Attempts = attempts
+	meta.StatusCode = res.statusCode
+	meta.ResponseBytes = int64(len(res.blob))
+	meta.Quota = res.quota
+
+	if err != nil {
+		meta.Error = err
+
+		return meta, nil, err
+	}
+
+	var payload freeCurrencyAPIResponse
+
+	if err := json.Unmarshal(res.blob, &payload); err != nil {
+		meta.Error = errors.Wrapf(err, "failed to decode the %s exchange rates", kind)
+
+		return meta, nil, meta.Error
+	}
+
+	rates, err := payload.rates()
+	if err != nil {
+		meta.Error = err
+
+		return meta, nil, err
+	}
+
+	meta.Status = "success"
+
+	return meta, rates, nil
+}
+
+// fetchResult represents the result of one attempt.
+type fetchResult struct {
+	blob       []byte
+	statusCode int
+	quota      *Quota
+	retry      bool
+	err        error
+}
+
+// fetch makes one attempt limited by the timeout of each request, the result has the body and the status
+// code of the response and tells if the error could be solved by trying again.
+func (fc *freeCurrencyApi) fetch(parent context.Context, u *url.URL) fetchResult {
+	resCh := make(chan fetchResult, 1)
+
 	// set the timeout of each request
-	ctx, cancel = context.WithTimeout(ctx, fc.Timeout)
+	ctx, cancel := context.WithTimeout(parent, fc.Timeout)
 	defer cancel()
 
 	go func() {
 		// preparing the request
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fc.URL.String(), http.NoBody)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), http.NoBody)
 		if err != nil {
-			errCh <- err
+			resCh <- fetchResult{err: err}
 
 			return
 		}
@@ -65,43 +207,44 @@ func (fc *freeCurrencyApi) GetLatestExchangeRates(ctx context.Context) (*Metadat
 		// making the request
 		res, err := fc.client.Do(req)
 		if err != nil {
-			errCh <- err
+			resCh <- fetchResult{retry: true, err: err}
 
 			return
 		}
+		defer res.Body.Close()
 
 		blob, err := io.ReadAll(res.Body)
 		if err != nil {
-			errCh <- err
+			resCh <- fetchResult{statusCode: res.StatusCode, quota: parseQuota(res.Header), retry: true, err: err}
 
 			return
 		}
-		defer res.Body.Close()
 
-		resCh <- blob
+		resCh <- fetchResult{
+			blob:       blob,
+			statusCode: res.StatusCode,
+			quota:      parseQuota(res.Header),
+			retry:      fc.Retry.retryableStatusCode(res.StatusCode),
+			err:        checkStatusCode(res.StatusCode, blob),
+		}
 	}()
 
 	select {
 	case <-ctx.Done():
-		meta.Error = errors.Wrap(ctx.Err(), "the proccess ends before finish by timeout")
-		meta.Status = "failure"
-
-		return meta, nil, meta.Error
+		// the timeout of the attempt is tried again, but not the end of the parent context
+		return fetchResult{
+			retry: parent.Err() == nil,
+			err:   errors.Wrap(ctx.Err(), "the proccess ends before finish by timeout"),
+		}
 	case res := <-resCh:
-		meta.Status = "success"
-
-		return meta, res, nil
-	case err := <-errCh:
-		meta.Error = err
-		meta.Status = "failure"
-
-		return meta, nil, err
+		return res
 	}
 }
 
-// GetTimeoutRequest returns the timeout set for requests.
+// GetTimeoutRequest returns the longest time a call could take, which is the timeout of each request
+// for all the attempts plus the delays between them.
 func (fc *freeCurrencyApi) GetTimeoutRequest() time.Duration {
-	return fc.Timeout
+	return fc.Retry.budget(fc.Timeout)
 }
 
 // NewFreeCurrencyAPI creates a new Free Currency Api Provider.
@@ -137,5 +280,6 @@ func DefaultFreeCurrencyAPIConfig() *CurrencyConfig {
 		URL:     u,
 		APIKey:  os.Getenv("FREE_CURRENCY_API_KEY"),
 		Timeout: reqTimeout,
+		Retry:   DefaultEnvRetryPolicy(),
 	}
 }
@@ -0,0 +1,216 @@
+package providers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/PacoDw/currency/providers"
+	"github.com/stretchr/testify/assert"
+)
+
+// newFreeCurrencyAPI creates a Free Currency Api Provider that makes the requests to a server
+// answering with the payload.
+func newFreeCurrencyAPI(t *testing.T, payload string) (providers.Currencier, func()) {
+	t.Helper()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.EqualValues(t, "API_KEY", r.Header.Get("apikey"))
+
+		w.Write([]byte(payload))
+	}))
+
+	u, err := url.Parse(ts.URL)
+	assert.Nil(t, err)
+
+	return providers.NewFreeCurrencyAPI(&providers.CurrencyConfig{
+		URL:     u,
+		APIKey:  "API_KEY",
+		Timeout: time.Second,
+	}), ts.Close
+}
+
+func TestFreeCurrencyAPIGetLatestExchangeRates(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		p, closeFn := newFreeCurrencyAPI(t, `{
+			"meta": {"last_updated_at": "2022-10-18T23:59:59Z"},
+			"data": {
+				"MXN": {"code": "MXN", "value": 20.0512},
+				"USD": {"code": "USD", "value": 1},
+				"VND": {"code": "VND", "value": 24867.123456789012345678},
+				"BTC": {"code": "BTC", "value": 0.000051234567890123456789}
+			}
+		}`)
+		defer closeFn()
+
+		meta, rates, err := p.GetLatestExchangeRates(context.Background())
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, providers.FreeCurrencyAPIName, meta.Provider)
+		assert.EqualValues(t, "success", meta.Status)
+		assert.EqualValues(t, http.StatusOK, meta.StatusCode)
+		assert.Greater(t, meta.ResponseBytes, int64(0))
+		assert.Empty(t, meta.ErrorMessage())
+
+		byCode := ratesByCode(rates)
+
+		assert.Len(t, byCode, 4)
+		assert.EqualValues(t, "20.0512", byCode["MXN"].Value.String())
+		assert.EqualValues(t, "24867.123456789012345678", byCode["VND"].Value.String())
+		assert.EqualValues(t, "0.000051234567890123456789", byCode["BTC"].Value.String())
+		assert.EqualValues(t, time.Date(2022, 10, 18, 23, 59, 59, 0, time.UTC), byCode["MXN"].LastUpdatedAt)
+	})
+
+	tests := []struct {
+		name    string
+		payload string
+		err     string
+	}{
+		{
+			name:    "malformed payload",
+			payload: `{"meta":`,
+			err:     "failed to decode the latest exchange rates: unexpected end of JSON input",
+		},
+		{
+			name:    "wrong type",
+			payload: `{"meta": {"last_updated_at": "2022-10-18T23:59:59Z"}, "data": {"MXN": {"code": "MXN", "value": "twenty"}}}`,
+			err:     "failed to decode the latest exchange rates",
+		},
+		{
+			name:    "without meta",
+			payload: `{"data": {"MXN": {"code": "MXN", "value": 20.05}}}`,
+			err:     "the rate last updated time of (MXN) must not be empty",
+		},
+		{
+			name:    "without data",
+			payload: `{"meta": {"last_updated_at": "2022-10-18T23:59:59Z"}}`,
+			err:     "the snapshot has no rates",
+		},
+		{
+			name:    "code mismatch",
+			payload: `{"meta": {"last_updated_at": "2022-10-18T23:59:59Z"}, "data": {"MXN": {"code": "USD", "value": 1}}}`,
+			err:     "the rate code (USD) does not match its key (MXN)",
+		},
+		{
+			name:    "negative value",
+			payload: `{"meta": {"last_updated_at": "2022-10-18T23:59:59Z"}, "data": {"MXN": {"code": "MXN", "value": -1}}}`,
+			err:     "the rate value of (MXN) must be a positive number",
+		},
+	}
+
+	for i := range tests {
+		tt := tests[i]
+
+		t.Run(tt.name, func(t *testing.T) {
+			p, closeFn := newFreeCurrencyAPI(t, tt.payload)
+			defer closeFn()
+
+			meta, rates, err := p.GetLatestExchangeRates(context.Background())
+
+			assert.ErrorContains(t, err, tt.err)
+			assert.EqualValues(t, "failure", meta.Status)
+			assert.Nil(t, rates)
+		})
+	}
+}
+
+func TestFreeCurrencyAPIUnexpectedStatusCode(t *testing.T) {
+	body := `{"message": "Invalid authentication credentials"}`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(body))
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	assert.Nil(t, err)
+
+	p := providers.NewFreeCurrencyAPI(&providers.CurrencyConfig{URL: u, APIKey: "API_KEY", Timeout: time.Second})
+
+	meta, rates, err := p.GetLatestExchangeRates(context.Background())
+
+	assert.EqualError(t, err, "unexpected status code 401: "+body)
+	assert.Nil(t, rates)
+	assert.EqualValues(t, "failure", meta.Status)
+	assert.EqualValues(t, http.StatusUnauthorized, meta.StatusCode)
+	assert.EqualValues(t, len(body), meta.ResponseBytes)
+	assert.EqualValues(t, err.Error(), meta.ErrorMessage())
+}
+
+func TestFreeCurrencyAPIQuota(t *testing.T) {
+	headers := map[string]string{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range headers {
+			w.Header().Set(k, v)
+		}
+
+		w.Write([]byte(`{"meta": {"last_updated_at": "2022-10-18T23:59:59Z"}, "data": {"USD": {"code": "USD", "value": 1}}}`))
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	assert.Nil(t, err)
+
+	p := providers.NewFreeCurrencyAPI(&providers.CurrencyConfig{URL: u, APIKey: "API_KEY", Timeout: time.Second})
+
+	t.Run("without headers", func(t *testing.T) {
+		meta, _, err := p.GetLatestExchangeRates(context.Background())
+
+		assert.Nil(t, err)
+		assert.Nil(t, meta.Quota)
+	})
+
+	t.Run("with headers", func(t *testing.T) {
+		headers["X-RateLimit-Limit-Quota-Month"] = "300"
+		headers["X-RateLimit-Remaining-Quota-Month"] = "299"
+
+		meta, _, err := p.GetLatestExchangeRates(context.Background())
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, &providers.Quota{Limit: 300, Remaining: 299}, meta.Quota)
+	})
+
+	t.Run("invalid headers", func(t *testing.T) {
+		headers["X-RateLimit-Remaining-Quota-Month"] = "unknown"
+
+		meta, _, err := p.GetLatestExchangeRates(context.Background())
+
+		assert.Nil(t, err)
+		assert.Nil(t, meta.Quota)
+	})
+}
+
+func TestFreeCurrencyAPIGetHistoricalExchangeRates(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.EqualValues(t, "/v3/historical", r.URL.Path)
+		assert.EqualValues(t, "2022-01-01", r.URL.Query().Get("date"))
+
+		w.Write([]byte(`{"meta": {"last_updated_at": "2022-01-01T23:59:59Z"}, "data": {"MXN": {"code": "MXN", "value": 20.5}}}`))
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL + "/v3/latest")
+	assert.Nil(t, err)
+
+	p := providers.NewFreeCurrencyAPI(&providers.CurrencyConfig{URL: u, APIKey: "API_KEY", Timeout: time.Second})
+
+	hp, ok := p.(providers.HistoricalCurrencier)
+	assert.True(t, ok)
+
+	// the date is taken in UTC
+	date := time.Date(2021, 12, 31, 20, 0, 0, 0, time.FixedZone("UTC-6", -6*60*60))
+
+	meta, rates, err := hp.GetHistoricalExchangeRates(context.Background(), date)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "success", meta.Status)
+	assert.Contains(t, meta.URL, "/v3/historical?date=2022-01-01")
+	assert.Len(t, rates, 1)
+	assert.EqualValues(t, "20.5", rates[0].Value.String())
+	assert.EqualValues(t, time.Date(2022, 1, 1, 23, 59, 59, 0, time.UTC), rates[0].LastUpdatedAt)
+}
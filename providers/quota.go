@@ -0,0 +1,36 @@
+package providers
+
+import (
+	"net/http"
+	"strconv"
+)
+
+const (
+	// quotaLimitHeader is the header with the monthly requests allowed by the account.
+	quotaLimitHeader = "X-RateLimit-Limit-Quota-Month"
+
+	// quotaRemainingHeader is the header with the monthly requests that are left.
+	quotaRemainingHeader = "X-RateLimit-Remaining-Quota-Month"
+)
+
+// Quota represents the monthly quota of requests of the Currency Provider account.
+type Quota struct {
+	Limit     int64 `json:"limit"`
+	Remaining int64 `json:"remaining"`
+}
+
+// parseQuota reads the quota from the rate-limit headers of the response, it returns nil when the
+// headers are not present or they are not numbers.
+func parseQuota(h http.Header) *Quota {
+	limit, err := strconv.ParseInt(h.Get(quotaLimitHeader), 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	remaining, err := strconv.ParseInt(h.Get(quotaRemainingHeader), 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	return &Quota{Limit: limit, Remaining: remaining}
+}
@@ -0,0 +1,54 @@
+package providers
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// rateCodeRegexp matches a currency code, the Currency Providers could return codes of crypto
+// currencies which are longer than 3 letters.
+var rateCodeRegexp = regexp.MustCompile("^[A-Z]{3,10}$")
+
+// Rate represents the exchange rate of one currency against the base currency of the Currency
+// Provider, the value is an exact decimal so it is stored without losing precision.
+type Rate struct {
+	Code          string
+	Value         decimal.Decimal
+	LastUpdatedAt time.Time
+}
+
+// Valid validates that the rate has a proper code, a positive value and the time of the update.
+func (r Rate) Valid() error {
+	if !rateCodeRegexp.MatchString(r.Code) {
+		return errors.Errorf("the rate code (%s) is invalid", r.Code)
+	}
+
+	if !r.Value.IsPositive() {
+		return errors.Errorf("the rate value of (%s) must be a positive number", r.Code)
+	}
+
+	if r.LastUpdatedAt.IsZero() {
+		return errors.Errorf("the rate last updated time of (%s) must not be empty", r.Code)
+	}
+
+	return nil
+}
+
+// validRates validates that there is at least one rate and all of them are valid, so a snapshot
+// is never stored partially.
+func validRates(rates []Rate) error {
+	if len(rates) == 0 {
+		return errors.New("the snapshot has no rates")
+	}
+
+	for i := range rates {
+		if err := rates[i].Valid(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+package providers
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RetryPolicy represents how a failed request to the Currency Provider is tried again, the delay
+// between the attempts grows exponentially from BaseDelay up to MaxDelay and a random jitter is
+// applied so the replicas don't retry at the same time.
+type RetryPolicy struct {
+	// MaxAttempts represents the number of attempts including the first one, if it is less than 1
+	// only one attempt is made.
+	MaxAttempts int
+
+	// BaseDelay represents the delay before the second attempt.
+	BaseDelay time.Duration
+
+	// MaxDelay represents the maximum delay between two attempts.
+	MaxDelay time.Duration
+
+	// RetryableStatusCodes represents the http status codes that are tried again, the network errors
+	// and the timeouts of each attempt are always tried again.
+	RetryableStatusCodes []int
+}
+
+// DefaultRetryPolicy returns the policy used when the env variables are not set.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    2 * time.Second,
+		RetryableStatusCodes: []int{
+			http.StatusRequestTimeout,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// DefaultEnvRetryPolicy takes the default policy and overrides it with the env variables
+// REQUEST_MAX_ATTEMPTS, REQUEST_RETRY_BASE_DELAY, REQUEST_RETRY_MAX_DELAY and
+// REQUEST_RETRY_STATUS_CODES which is a comma separated list, e.g.: 429,503.
+func DefaultEnvRetryPolicy() RetryPolicy {
+	p := DefaultRetryPolicy()
+
+	if v := os.Getenv("REQUEST_MAX_ATTEMPTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			panic(errors.Wrap(err, "the REQUEST_MAX_ATTEMPTS env variable must be a number"))
+		}
+
+		p.MaxAttempts = n
+	}
+
+	if v := os.Getenv("REQUEST_RETRY_BASE_DELAY"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			panic(errors.Wrap(err, "the REQUEST_RETRY_BASE_DELAY env variable must be a duration"))
+		}
+
+		p.BaseDelay = d
+	}
+
+	if v := os.Getenv("REQUEST_RETRY_MAX_DELAY"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			panic(errors.Wrap(err, "the REQUEST_RETRY_MAX_DELAY env variable must be a duration"))
+		}
+
+		p.MaxDelay = d
+	}
+
+	if v := os.Getenv("REQUEST_RETRY_STATUS_CODES"); v != "" {
+		p.RetryableStatusCodes = nil
+
+		for _, code := range strings.Split(v, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(code))
+			if err != nil {
+				panic(errors.Wrap(err, "the REQUEST_RETRY_STATUS_CODES env variable must be a list of numbers"))
+			}
+
+			p.RetryableStatusCodes = append(p.RetryableStatusCodes, n)
+		}
+	}
+
+	return p
+}
+
+// attempts returns the number of attempts, at least one.
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+
+	return p.MaxAttempts
+}
+
+// retryableStatusCode checks if the status code must be tried again.
+func (p RetryPolicy) retryableStatusCode(code int) bool {
+	for i := range p.RetryableStatusCodes {
+		if p.RetryableStatusCodes[i] == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// maxDelay returns the maximum delay before the attempt, it is BaseDelay * 2^(attempt-2) capped by
+// MaxDelay, the attempts start at 1 so the second attempt waits BaseDelay at most.
+func (p RetryPolicy) maxDelay(attempt int) time.Duration {
+	d := p.BaseDelay
+
+	for i := 2; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+
+	return d
+}
+
+// delay returns a random delay between 0 and maxDelay, known as full jitter.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.maxDelay(attempt)
+	if d <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(d) + 1)) //nolint:gosec // the jitter doesn't need a secure random
+}
+
+// budget returns the longest time that all the attempts could take when each one takes the timeout.
+func (p RetryPolicy) budget(timeout time.Duration) time.Duration {
+	total := timeout
+
+	for attempt := 2; attempt <= p.attempts(); attempt++ {
+		total += p.maxDelay(attempt) + timeout
+	}
+
+	return total
+}
+
+// do calls fn until it succeeds, it returns an error that must not be tried again or the attempts are
+// exhausted, it returns the number of attempts made. A delay which would go beyond the deadline of the
+// context is not waited, so the last error is returned before the deadline.
+func (p RetryPolicy) do(ctx context.Context, fn func(ctx context.Context) (retry bool, err error)) (int, error) {
+	for attempt := 1; ; attempt++ {
+		retry, err := fn(ctx)
+		if err == nil || !retry || attempt >= p.attempts() || ctx.Err() != nil {
+			return attempt, err
+		}
+
+		delay := p.delay(attempt + 1)
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return attempt, err
+		}
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return attempt, err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package providers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/PacoDw/currency/providers"
+	"github.com/stretchr/testify/assert"
+)
+
+// newFlakyFreeCurrencyAPI creates a Free Currency Api Provider that makes the requests to a server
+// answering with the status codes in order and then with the payload, it returns the number of
+// requests received.
+func newFlakyFreeCurrencyAPI(t *testing.T, policy providers.RetryPolicy, codes ...int) (providers.Currencier, *int32, func()) {
+	t.Helper()
+
+	var calls int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+
+		if int(n) <= len(codes) {
+			w.WriteHeader(codes[n-1])
+
+			return
+		}
+
+		w.Write([]byte(`{"meta": {"last_updated_at": "2022-10-18T23:59:59Z"}, "data": {"USD": {"code": "USD", "value": 1}}}`))
+	}))
+
+	u, err := url.Parse(ts.URL)
+	assert.Nil(t, err)
+
+	return providers.NewFreeCurrencyAPI(&providers.CurrencyConfig{
+		URL:     u,
+		APIKey:  "API_KEY",
+		Timeout: time.Second,
+		Retry:   policy,
+	}), &calls, ts.Close
+}
+
+func TestFreeCurrencyAPIRetry(t *testing.T) {
+	policy := providers.RetryPolicy{
+		MaxAttempts:          3,
+		BaseDelay:            time.Millisecond,
+		MaxDelay:             5 * time.Millisecond,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests},
+	}
+
+	t.Run("success after retrying", func(t *testing.T) {
+		p, calls, closeFn := newFlakyFreeCurrencyAPI(t, policy, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+		defer closeFn()
+
+		meta, rates, err := p.GetLatestExchangeRates(context.Background())
+
+		assert.Nil(t, err)
+		assert.Len(t, rates, 1)
+		assert.EqualValues(t, "success", meta.Status)
+		assert.EqualValues(t, 3, meta.Attempts)
+		assert.EqualValues(t, 3, atomic.LoadInt32(calls))
+	})
+
+	t.Run("attempts exhausted", func(t *testing.T) {
+		p, calls, closeFn := newFlakyFreeCurrencyAPI(t, policy,
+			http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+		defer closeFn()
+
+		meta, _, err := p.GetLatestExchangeRates(context.Background())
+
+		assert.EqualError(t, err, "unexpected status code 503")
+		assert.EqualValues(t, "failure", meta.Status)
+		assert.EqualValues(t, 3, meta.Attempts)
+		assert.EqualValues(t, 3, atomic.LoadInt32(calls))
+	})
+
+	t.Run("status code not retryable", func(t *testing.T) {
+		p, calls, closeFn := newFlakyFreeCurrencyAPI(t, policy, http.StatusBadRequest)
+		defer closeFn()
+
+		meta, _, err := p.GetLatestExchangeRates(context.Background())
+
+		assert.NotNil(t, err)
+		assert.EqualValues(t, 1, meta.Attempts)
+		assert.EqualValues(t, 1, atomic.LoadInt32(calls))
+	})
+
+	t.Run("the delay does not go beyond the deadline", func(t *testing.T) {
+		slow := policy
+		slow.BaseDelay = time.Hour
+		slow.MaxDelay = time.Hour
+
+		p, calls, closeFn := newFlakyFreeCurrencyAPI(t, slow, http.StatusServiceUnavailable)
+		defer closeFn()
+
+		// the jitter could pick a short delay, so the deadline is shorter than any useful delay
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		meta, _, err := p.GetLatestExchangeRates(ctx)
+
+		assert.NotNil(t, err)
+		assert.Less(t, int64(time.Since(start)), int64(time.Second))
+		assert.LessOrEqual(t, meta.Attempts, 2)
+		assert.EqualValues(t, meta.Attempts, atomic.LoadInt32(calls))
+	})
+
+	t.Run("timeout budget", func(t *testing.T) {
+		p, _, closeFn := newFlakyFreeCurrencyAPI(t, policy)
+		defer closeFn()
+
+		// 3 attempts of 1s plus the delays of 1ms and 2ms
+		assert.EqualValues(t, 3*time.Second+3*time.Millisecond, p.GetTimeoutRequest())
+	})
+}
+
+func TestDefaultEnvRetryPolicy(t *testing.T) {
+	os.Setenv("REQUEST_MAX_ATTEMPTS", "5")
+	os.Setenv("REQUEST_RETRY_BASE_DELAY", "1s")
+	os.Setenv("REQUEST_RETRY_MAX_DELAY", "30s")
+	os.Setenv("REQUEST_RETRY_STATUS_CODES", "429, 503")
+
+	defer func() {
+		os.Unsetenv("REQUEST_MAX_ATTEMPTS")
+		os.Unsetenv("REQUEST_RETRY_BASE_DELAY")
+		os.Unsetenv("REQUEST_RETRY_MAX_DELAY")
+		os.Unsetenv("REQUEST_RETRY_STATUS_CODES")
+	}()
+
+	assert.EqualValues(t, providers.RetryPolicy{
+		MaxAttempts:          5,
+		BaseDelay:            time.Second,
+		MaxDelay:             30 * time.Second,
+		RetryableStatusCodes: []int{429, 503},
+	}, providers.DefaultEnvRetryPolicy())
+
+	os.Setenv("REQUEST_MAX_ATTEMPTS", "many")
+
+	assert.Panics(t, func() { providers.DefaultEnvRetryPolicy() })
+}
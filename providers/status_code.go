@@ -0,0 +1,30 @@
+package providers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// maxErrorBodyBytes limits the part of the body added to the error of an unexpected status code.
+const maxErrorBodyBytes = 256
+
+// checkStatusCode returns an error when the status code is not 2xx, the beginning of the body is
+// added to the error because the Currency Providers usually explain there what was wrong.
+func checkStatusCode(code int, body []byte) error {
+	if code >= http.StatusOK && code < http.StatusMultipleChoices {
+		return nil
+	}
+
+	msg := strings.TrimSpace(string(body))
+	if len(msg) > maxErrorBodyBytes {
+		msg = msg[:maxErrorBodyBytes] + "..."
+	}
+
+	if msg == "" {
+		return errors.Errorf("unexpected status code %d", code)
+	}
+
+	return errors.Errorf("unexpected status code %d: %s", code, msg)
+}
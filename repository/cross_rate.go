@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// CrossRate represents the rate between two currencies computed through the base currency of
+// the Currency Provider, both legs are taken from the same snapshot (request_id) so the rate is
+// consistent even when the currencies were stored at different moments.
+type CrossRate struct {
+	// Rate represents how many units of the ToRate currency are equal to one unit of the FromRate
+	// currency, it is rounded to decimal.DivisionPrecision digits.
+	Rate decimal.Decimal `json:"rate"`
+
+	// RequestID represents the snapshot where both legs were taken from.
+	RequestID int64 `json:"request_id"`
+
+	// AsOf represents the last_updated_at of the snapshot.
+	AsOf time.Time `json:"as_of"`
+
+	// FromRate and ToRate represent the stored values against the base currency.
+	FromRate CurrencyValue `json:"from_rate"`
+	ToRate   CurrencyValue `json:"to_rate"`
+}
+
+// Convert converts the amount from the FromRate currency to the ToRate currency.
+func (cr CrossRate) Convert(amount decimal.Decimal) decimal.Decimal {
+	return amount.Mul(cr.Rate)
+}
+
+// NewCrossRate computes the rate between two currency values of the same snapshot, the values
+// must be relative to the same base currency.
+func NewCrossRate(from, to CurrencyValue) (CrossRate, error) {
+	if from.RequestID != to.RequestID {
+		return CrossRate{}, errors.Errorf("the currencies (%s) and (%s) belong to different snapshots", from.Name, to.Name)
+	}
+
+	if from.Value.IsZero() {
+		return CrossRate{}, errors.Errorf("the rate of the currency (%s) is zero", from.Name)
+	}
+
+	return CrossRate{
+		Rate:      to.Value.Div(from.Value),
+		RequestID: from.RequestID,
+		AsOf:      from.LastUdatedAt,
+		FromRate:  from,
+		ToRate:    to,
+	}, nil
+}
+
+// GetCrossRate computes the rate of the latest snapshot, or bucket, with both currencies.
+func (service *CurrencyValueSQLService) GetCrossRate(from, to string, asOf *time.Time) (CrossRate, error) {
+	var (
+		fromVal  = CurrencyValue{Name: from}
+		toVal    = CurrencyValue{Name: to}
+		asOfCond interface{}
+	)
+
+	if asOf != nil && !asOf.IsZero() {
+		asOfCond = *asOf
+	}
+
+	err := service.db.QueryRow(`
+		SELECT
+			f.request_id,
+			f.value,
+			f.last_updated_at::TIMESTAMP,
+			t.value,
+			t.last_updated_at::TIMESTAMP
+		FROM
+			currencies_values f
+		INNER JOIN
+			currencies_values t ON t.request_id = f.request_id AND t.name = $2
+		WHERE
+			f.name = $1
+		AND
+			($3::TIMESTAMP IS NULL OR f.last_updated_at::TIMESTAMP <= $3::TIMESTAMP)
+		ORDER BY
+			f.last_updated_at DESC,
+			f.request_id DESC
+		LIMIT 1;
+	`, from, to, asOfCond).Scan(
+		&fromVal.RequestID,
+		&fromVal.Value,
+		&fromVal.LastUdatedAt,
+		&toVal.Value,
+		&toVal.LastUdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return service.getRollupCrossRate(from, to, asOfCond)
+	}
+
+	if err != nil {
+		return CrossRate{}, errors.Wrap(err, "failed to get the snapshot of the cross rate")
+	}
+
+	toVal.RequestID = fromVal.RequestID
+
+	return NewCrossRate(fromVal, toVal)
+}
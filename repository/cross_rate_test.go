@@ -0,0 +1,95 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PacoDw/currency/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCrossRate(t *testing.T) {
+	lastUpdated := time.Date(2022, 10, 17, 17, 23, 34, 0, time.UTC)
+
+	eur := repository.CurrencyValue{Name: "EUR", RequestID: 7, Value: decimal.NewFromFloat(0.5), LastUdatedAt: lastUpdated}
+	jpy := repository.CurrencyValue{Name: "JPY", RequestID: 7, Value: decimal.NewFromFloat(75), LastUdatedAt: lastUpdated}
+
+	t.Run("through the base currency", func(t *testing.T) {
+		cr, err := repository.NewCrossRate(eur, jpy)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "150", cr.Rate.String())
+		assert.EqualValues(t, 7, cr.RequestID)
+		assert.EqualValues(t, lastUpdated, cr.AsOf)
+		assert.EqualValues(t, "300", cr.Convert(decimal.NewFromInt(2)).String())
+	})
+
+	t.Run("different snapshots", func(t *testing.T) {
+		other := jpy
+		other.RequestID = 8
+
+		_, err := repository.NewCrossRate(eur, other)
+
+		assert.EqualError(t, err, "the currencies (EUR) and (JPY) belong to different snapshots")
+	})
+
+	t.Run("zero rate", func(t *testing.T) {
+		zero := eur
+		zero.Value = decimal.Zero
+
+		_, err := repository.NewCrossRate(zero, jpy)
+
+		assert.EqualError(t, err, "the rate of the currency (EUR) is zero")
+	})
+}
+
+func TestSQLGetCrossRate(t *testing.T) {
+	TestEnvDBConnectionVariables(t)
+
+	conn := repository.NewSQLConnection(config)
+
+	assert.Condition(t, func() (success bool) { return assert.NotNil(t, conn) })
+
+	// days far from the stored rates, the values already stored by a previous run are skipped
+	at := func(day, hour int) time.Time {
+		return time.Date(2003, 10, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	insert := func(updatedAt time.Time, values map[string]float64) {
+		id, err := conn.RequestStatus.Insert(repository.RequestStatus{Status: "success", RequestedAt: updatedAt})
+		assert.Condition(t, func() (success bool) { return assert.Nil(t, err) })
+
+		cvs := make([]repository.CurrencyValue, 0, len(values))
+		for name, v := range values {
+			cvs = append(cvs, repository.CurrencyValue{Name: name, RequestID: id, Value: decimal.NewFromFloat(v), LastUdatedAt: updatedAt})
+		}
+
+		_, _, err = conn.CurrencyValue.BulkInsert(cvs)
+		assert.Condition(t, func() (success bool) { return assert.Nil(t, err) })
+	}
+
+	// the latest snapshot doesn't have both currencies
+	insert(at(17, 12), map[string]float64{"CRA": 2, "CRB": 30})
+	insert(at(18, 12), map[string]float64{"CRA": 4, "CRB": 80})
+	insert(at(19, 12), map[string]float64{"CRA": 5})
+
+	cr, err := conn.CurrencyValue.GetCrossRate("CRA", "CRB", nil)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "20", cr.Rate.String())
+	assert.True(t, at(18, 12).Equal(cr.AsOf), cr.AsOf.String())
+
+	asOf := at(18, 0)
+
+	cr, err = conn.CurrencyValue.GetCrossRate("CRA", "CRB", &asOf)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "15", cr.Rate.String())
+	assert.True(t, at(17, 12).Equal(cr.AsOf), cr.AsOf.String())
+
+	asOf = at(17, 0)
+
+	_, err = conn.CurrencyValue.GetCrossRate("CRA", "CRB", &asOf)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// Bucket represents the size of the time buckets used to aggregate the currency values.
+type Bucket string
+
+const (
+	// BucketHour aggregates the values by hour.
+	BucketHour Bucket = Bucket("1h")
+
+	// BucketDay aggregates the values by day.
+	BucketDay Bucket = Bucket("1d")
+
+	// BucketWeek aggregates the values by week, the weeks start on Monday.
+	BucketWeek Bucket = Bucket("1w")
+)
+
+// Valid checks if the bucket is one of the supported sizes.
+func (b Bucket) Valid() bool {
+	return b == BucketHour || b == BucketDay || b == BucketWeek
+}
+
+// unit returns the unit used by date_trunc to truncate the timestamps.
+func (b Bucket) unit() string {
+	switch b {
+	case BucketHour:
+		return "hour"
+	case BucketDay:
+		return "day"
+	case BucketWeek:
+		return "week"
+	default:
+		return ""
+	}
+}
+
+// Truncate returns the start of the bucket that contains t, it behaves like date_trunc.
+func (b Bucket) Truncate(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+	switch b {
+	case BucketHour:
+		return day.Add(time.Duration(t.Hour()) * time.Hour)
+	case BucketDay:
+		return day
+	case BucketWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	default:
+		return t
+	}
+}
+
+// CurrencyBucket represents the aggregation of the values of a currency in a time bucket, the average
+// is rounded to decimal.DivisionPrecision digits.
+type CurrencyBucket struct {
+	Name   string          `json:"name"`
+	Bucket time.Time       `json:"bucket"`
+	Open   decimal.Decimal `json:"open"`
+	High   decimal.Decimal `json:"high"`
+	Low    decimal.Decimal `json:"low"`
+	Close  decimal.Decimal `json:"close"`
+	Avg    decimal.Decimal `json:"avg"`
+	Count  int64           `json:"count"`
+}
+
+// ListCurrencyBuckets aggregates the values and their rollups in time buckets.
+func (service *CurrencyValueSQLService) ListCurrencyBuckets(currency string, bucket Bucket, finit, fend *time.Time) ([]CurrencyBucket, error) {
+	if !bucket.Valid() {
+		return nil, invalidArgumentf("the bucket (%s) is not supported", bucket)
+	}
+
+	if finit == nil || fend == nil || finit.IsZero() || fend.IsZero() {
+		init, end, err := service.GetFinitAndFend()
+		if err != nil {
+			return nil, err
+		}
+
+		if finit == nil || finit.IsZero() {
+			finit = &init
+		}
+
+		if fend == nil || fend.IsZero() {
+			fend = &end
+		}
+	}
+
+	// a NULL currency means all the currencies
+	var currencyCond interface{}
+	if !strings.EqualFold(currency, "all") {
+		currencyCond = currency
+	}
+
+	rows, err := service.db.Query(`
+		SELECT
+			name,
+			date_trunc($1::TEXT, series.bucket) AS bucket,
+			(ARRAY_AGG(open ORDER BY opened_at ASC, id ASC))[1] AS open,
+			MAX(high) AS high,
+			MIN(low) AS low,
+			(ARRAY_AGG(close ORDER BY closed_at DESC, id DESC))[1] AS close,
+			SUM(sum) / SUM(count) AS avg,
+			SUM(count)::BIGINT AS count
+		FROM`+currencySeries+`
+		WHERE
+			closed_at >= $2::TIMESTAMP
+		AND
+			closed_at <= $3::TIMESTAMP
+		AND
+			($4::VARCHAR IS NULL OR name = $4::VARCHAR)
+		GROUP BY
+			name,
+			date_trunc($1::TEXT, series.bucket)
+		ORDER BY
+			name,
+			bucket;
+	`, bucket.unit(), *finit, *fend, currencyCond)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to aggregate the currencies")
+	}
+	defer rows.Close()
+
+	buckets := make([]CurrencyBucket, 0)
+
+	for rows.Next() {
+		var cb CurrencyBucket
+
+		if err := rows.Scan(
+			&cb.Name,
+			&cb.Bucket,
+			&cb.Open,
+			&cb.High,
+			&cb.Low,
+			&cb.Close,
+			&cb.Avg,
+			&cb.Count,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scanning multiple records")
+		}
+
+		buckets = append(buckets, cb)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to iterate the records")
+	}
+
+	return buckets, nil
+}
@@ -0,0 +1,135 @@
+package repository_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/PacoDw/currency/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// formatBuckets formats the buckets to compare them easily, the decimals are compared by its value.
+func formatBuckets(buckets []repository.CurrencyBucket) []string {
+	formatted := make([]string, 0, len(buckets))
+
+	for _, cb := range buckets {
+		formatted = append(formatted, fmt.Sprintf("%s %s open=%s high=%s low=%s close=%s avg=%s count=%d",
+			cb.Name, cb.Bucket.Format(time.RFC3339), cb.Open, cb.High, cb.Low, cb.Close, cb.Avg, cb.Count))
+	}
+
+	return formatted
+}
+
+func TestBucketTruncate(t *testing.T) {
+	// it is a Wednesday
+	ts := time.Date(2022, 10, 19, 17, 23, 34, 123, time.UTC)
+
+	assert.EqualValues(t, time.Date(2022, 10, 19, 17, 0, 0, 0, time.UTC), repository.BucketHour.Truncate(ts))
+	assert.EqualValues(t, time.Date(2022, 10, 19, 0, 0, 0, 0, time.UTC), repository.BucketDay.Truncate(ts))
+	assert.EqualValues(t, time.Date(2022, 10, 17, 0, 0, 0, 0, time.UTC), repository.BucketWeek.Truncate(ts))
+
+	// a Sunday belongs to the week started the previous Monday
+	sunday := time.Date(2022, 10, 23, 1, 0, 0, 0, time.UTC)
+	assert.EqualValues(t, time.Date(2022, 10, 17, 0, 0, 0, 0, time.UTC), repository.BucketWeek.Truncate(sunday))
+
+	assert.False(t, repository.Bucket("5m").Valid())
+}
+
+func TestListCurrencyBuckets(t *testing.T) {
+	conn := repository.NewMemoryConnection()
+
+	id, err := conn.RequestStatus.Insert(repository.RequestStatus{Status: "success"})
+	assert.Nil(t, err)
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2022, 10, 19, hour, minute, 0, 0, time.UTC)
+	}
+
+	// the values are not inserted in order
+	_, _, err = conn.CurrencyValue.BulkInsert([]repository.CurrencyValue{
+		{Name: "MXN", RequestID: id, Value: decimal.NewFromFloat(21), LastUdatedAt: at(10, 30)},
+		{Name: "MXN", RequestID: id, Value: decimal.NewFromFloat(20), LastUdatedAt: at(10, 0)},
+		{Name: "MXN", RequestID: id, Value: decimal.NewFromFloat(23), LastUdatedAt: at(10, 45)},
+		{Name: "MXN", RequestID: id, Value: decimal.NewFromFloat(19), LastUdatedAt: at(10, 50)},
+		{Name: "MXN", RequestID: id, Value: decimal.NewFromFloat(22), LastUdatedAt: at(11, 5)},
+		{Name: "EUR", RequestID: id, Value: decimal.NewFromFloat(1), LastUdatedAt: at(10, 5)},
+	})
+	assert.Nil(t, err)
+
+	t.Run("hourly", func(t *testing.T) {
+		buckets, err := conn.CurrencyValue.ListCurrencyBuckets("MXN", repository.BucketHour, nil, nil)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, []string{
+			"MXN 2022-10-19T10:00:00Z open=20 high=23 low=19 close=19 avg=20.75 count=4",
+			"MXN 2022-10-19T11:00:00Z open=22 high=22 low=22 close=22 avg=22 count=1",
+		}, formatBuckets(buckets))
+	})
+
+	t.Run("daily for all the currencies", func(t *testing.T) {
+		finit := at(10, 10)
+
+		buckets, err := conn.CurrencyValue.ListCurrencyBuckets("all", repository.BucketDay, &finit, nil)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, []string{
+			"MXN 2022-10-19T00:00:00Z open=21 high=23 low=19 close=22 avg=21.25 count=4",
+		}, formatBuckets(buckets))
+	})
+
+	t.Run("unsupported bucket", func(t *testing.T) {
+		_, err := conn.CurrencyValue.ListCurrencyBuckets("MXN", repository.Bucket("5m"), nil, nil)
+
+		assert.EqualError(t, err, "the bucket (5m) is not supported")
+		assert.ErrorIs(t, err, repository.ErrInvalidArgument)
+	})
+}
+
+func TestSQLListCurrencyBuckets(t *testing.T) {
+	TestEnvDBConnectionVariables(t)
+
+	conn := repository.NewSQLConnection(config)
+
+	assert.Condition(t, func() (success bool) { return assert.NotNil(t, conn) })
+
+	id, err := conn.RequestStatus.Insert(repository.RequestStatus{Status: "success", RequestedAt: time.Now()})
+	assert.Condition(t, func() (success bool) { return assert.Nil(t, err) })
+
+	// a day far from the stored rates, the values already stored by a previous run are skipped
+	at := func(hour, minute int) time.Time {
+		return time.Date(2003, 10, 19, hour, minute, 0, 0, time.UTC)
+	}
+
+	_, _, err = conn.CurrencyValue.BulkInsert([]repository.CurrencyValue{
+		{Name: "BKT", RequestID: id, Value: decimal.NewFromFloat(21), LastUdatedAt: at(10, 30)},
+		{Name: "BKT", RequestID: id, Value: decimal.NewFromFloat(20), LastUdatedAt: at(10, 0)},
+		{Name: "BKT", RequestID: id, Value: decimal.NewFromFloat(23), LastUdatedAt: at(10, 45)},
+		{Name: "BKT", RequestID: id, Value: decimal.NewFromFloat(19), LastUdatedAt: at(10, 50)},
+		{Name: "BKT", RequestID: id, Value: decimal.NewFromFloat(22), LastUdatedAt: at(11, 5)},
+	})
+	assert.Condition(t, func() (success bool) { return assert.Nil(t, err) })
+
+	finit, fend := at(0, 0), at(23, 59)
+
+	buckets, err := conn.CurrencyValue.ListCurrencyBuckets("BKT", repository.BucketHour, &finit, &fend)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{
+		"BKT 2003-10-19T10:00:00Z open=20 high=23 low=19 close=19 avg=20.75 count=4",
+		"BKT 2003-10-19T11:00:00Z open=22 high=22 low=22 close=22 avg=22 count=1",
+	}, formatBuckets(buckets))
+
+	finit = at(10, 10)
+
+	buckets, err = conn.CurrencyValue.ListCurrencyBuckets("BKT", repository.BucketDay, &finit, &fend)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{
+		"BKT 2003-10-19T00:00:00Z open=21 high=23 low=19 close=22 avg=21.25 count=4",
+	}, formatBuckets(buckets))
+
+	_, err = conn.CurrencyValue.ListCurrencyBuckets("BKT", repository.Bucket("5m"), &finit, &fend)
+	assert.ErrorIs(t, err, repository.ErrInvalidArgument)
+}
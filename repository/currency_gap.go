@@ -0,0 +1,391 @@
+package repository
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CurrencyGap represents a window between two consecutive requests covering a currency in which one or
+// more runs of the schedule were expected but no request covered the currency. The failed requests made
+// to the Currency Provider inside the window tell if the job failed, a gap without failures means that
+// the job did not run or the Currency Provider did not return the currency.
+type CurrencyGap struct {
+	Name     string          `json:"name"`
+	From     time.Time       `json:"from"`
+	To       time.Time       `json:"to"`
+	Duration string          `json:"duration"`
+	Missing  int64           `json:"missing"`
+	Failures []RequestStatus `json:"failures"`
+}
+
+// Schedule tells when the values of the currencies are expected, e.g.: the schedule of the requests to the
+// Currency Provider.
+type Schedule interface {
+	// Next returns the time of the first run after t.
+	Next(t time.Time) time.Time
+}
+
+// maxScheduleRuns limits the runs of the schedule compared in a single query, a longer range must be
+// narrowed with finit and fend.
+const maxScheduleRuns = 100000
+
+// validateSchedule checks that the schedule has a next run, otherwise the values are never expected.
+func validateSchedule(sched Schedule) error {
+	if sched == nil {
+		return invalidArgumentf("the schedule must be set")
+	}
+
+	if now := time.Now(); !sched.Next(now).After(now) {
+		return invalidArgumentf("the schedule never runs")
+	}
+
+	return nil
+}
+
+// gapDates returns the range of the gaps, if finit is not set the first date stored is used and if fend
+// is not set the current time, so the requests made after the latest value stored are compared too.
+// The range is zero when nothing is stored.
+func gapDates(finit, fend *time.Time, stored func() (time.Time, time.Time, error)) (time.Time, time.Time, error) {
+	var from, to time.Time
+
+	if finit != nil {
+		from = *finit
+	}
+
+	if fend != nil {
+		to = *fend
+	}
+
+	if from.IsZero() {
+		first, _, err := stored()
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+
+		if first.IsZero() {
+			return time.Time{}, time.Time{}, nil
+		}
+
+		from = first
+	}
+
+	if to.IsZero() {
+		to = time.Now()
+	}
+
+	return from, to, nil
+}
+
+// scheduleRuns returns the runs of the schedule between finit and fend, both included. It fails when
+// there are more than maxScheduleRuns runs.
+func scheduleRuns(sched Schedule, finit, fend time.Time) ([]time.Time, error) {
+	runs := make([]time.Time, 0)
+
+	for prev, t := finit, sched.Next(finit); t.After(prev) && !t.After(fend); prev, t = t, sched.Next(t) {
+		if len(runs) == maxScheduleRuns {
+			return nil, invalidArgumentf("the schedule runs more than %d times between finit and fend", maxScheduleRuns)
+		}
+
+		runs = append(runs, t)
+	}
+
+	return runs, nil
+}
+
+// missingRuns returns the runs without request between two consecutive requests of a currency, the
+// last run of the window is the one made by the request at to, so a late request is not reported. The
+// runs must be sorted and it is not positive when nothing is missing.
+func missingRuns(runs []time.Time, from, to time.Time) int64 {
+	first := sort.Search(len(runs), func(i int) bool { return runs[i].After(from) })
+	last := sort.Search(len(runs), func(i int) bool { return runs[i].After(to) })
+
+	return int64(last-first) - 1
+}
+
+// newCurrencyGap creates the gap between two consecutive requests.
+func newCurrencyGap(name string, from, to time.Time, missing int64) CurrencyGap {
+	d := to.Sub(from)
+
+	return CurrencyGap{
+		Name:     name,
+		From:     from,
+		To:       to,
+		Duration: d.String(),
+		Missing:  missing,
+		Failures: make([]RequestStatus, 0),
+	}
+}
+
+// listGaps compares the requests made to the Currency Provider against the runs of the schedule. A
+// success request covers the currencies whose values it stored and an unchanged request covers the
+// currencies of the latest snapshot, the first requests cover the currencies stored before them. The
+// requests must be sorted by the time of the request and only the currency, or each currency when it is
+// 'all', is compared.
+func listGaps(currency string, runs []time.Time, stored []string, reqs []RequestStatus, values map[int64][]string) []CurrencyGap {
+	var (
+		gaps     = make([]CurrencyGap, 0)
+		failures = make([]RequestStatus, 0)
+
+		// the latest request covering each currency is only kept when the snapshot changes, the
+		// requests covering the same snapshot are compared once for all its currencies
+		last    = map[string]time.Time{}
+		prev    time.Time
+		current = filterCurrencies(currency, stored)
+	)
+
+	for _, rs := range reqs {
+		switch rs.Status {
+		case "failure":
+			failures = append(failures, rs)
+
+			continue
+		case "success":
+			// a request without values, e.g.: its values were stored by other replica, keeps the snapshot
+			names, ok := values[rs.ID]
+			if !ok {
+				break
+			}
+
+			if !prev.IsZero() {
+				for _, name := range current {
+					last[name] = prev
+				}
+			}
+
+			current, prev = filterCurrencies(currency, names), time.Time{}
+		}
+
+		for _, name := range current {
+			from, found := prev, true
+			if from.IsZero() {
+				from, found = last[name]
+			}
+
+			if !found {
+				continue
+			}
+
+			if missing := missingRuns(runs, from, rs.RequestedAt); missing > 0 {
+				gaps = append(gaps, newCurrencyGap(name, from, rs.RequestedAt, missing))
+			}
+		}
+
+		prev = rs.RequestedAt
+	}
+
+	sort.Slice(gaps, func(i, j int) bool {
+		if gaps[i].Name != gaps[j].Name {
+			return gaps[i].Name < gaps[j].Name
+		}
+
+		return gaps[i].From.Before(gaps[j].From)
+	})
+
+	attachFailures(gaps, failures)
+
+	return gaps
+}
+
+// filterCurrencies returns the names of the currency, or all of them when it is 'all'.
+func filterCurrencies(currency string, names []string) []string {
+	if strings.EqualFold(currency, "all") {
+		return names
+	}
+
+	for _, name := range names {
+		if strings.EqualFold(name, currency) {
+			return []string{name}
+		}
+	}
+
+	return nil
+}
+
+// attachFailures adds to each gap the failed requests made inside its window, the failures must be
+// sorted by the time of the request.
+func attachFailures(gaps []CurrencyGap, failures []RequestStatus) {
+	for i := range gaps {
+		first := sort.Search(len(failures), func(j int) bool { return failures[j].RequestedAt.After(gaps[i].From) })
+
+		for _, rs := range failures[first:] {
+			if !rs.RequestedAt.Before(gaps[i].To) {
+				break
+			}
+
+			gaps[i].Failures = append(gaps[i].Failures, rs)
+		}
+	}
+}
+
+// ListCurrencyGaps compares the requests against the runs of the schedule.
+func (service *CurrencyValueSQLService) ListCurrencyGaps(currency string, sched Schedule, finit, fend *time.Time) ([]CurrencyGap, error) {
+	if err := validateSchedule(sched); err != nil {
+		return nil, err
+	}
+
+	from, to, err := gapDates(finit, fend, service.GetFinitAndFend)
+	if err != nil {
+		return nil, err
+	}
+
+	if from.IsZero() {
+		return make([]CurrencyGap, 0), nil
+	}
+
+	runs, err := scheduleRuns(sched, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := service.listSnapshotBefore(from)
+	if err != nil {
+		return nil, err
+	}
+
+	reqs, err := service.listJobRequests(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := service.listRequestsCurrencies(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return listGaps(currency, runs, stored, reqs, values), nil
+}
+
+// listSnapshotBefore retrieves the currencies of the latest success request with values made before t.
+func (service *CurrencyValueSQLService) listSnapshotBefore(t time.Time) ([]string, error) {
+	rows, err := service.db.Query(`
+		SELECT
+			name
+		FROM
+			currencies_values
+		WHERE
+			request_id = (
+				SELECT
+					rs.id
+				FROM
+					requests_status rs
+				WHERE
+					rs.status = 'success'
+				AND
+					rs.requested_at < $1::TIMESTAMP
+				AND
+					EXISTS (SELECT 1 FROM currencies_values cv WHERE cv.request_id = rs.id)
+				ORDER BY
+					rs.requested_at DESC,
+					rs.id DESC
+				LIMIT 1
+			)
+		ORDER BY
+			name;
+	`, t)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the currencies of the latest snapshot")
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+
+	for rows.Next() {
+		var name string
+
+		if err := rows.Scan(&name); err != nil {
+			return nil, errors.Wrap(err, "failed to scanning multiple records")
+		}
+
+		names = append(names, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to iterate the records")
+	}
+
+	return names, nil
+}
+
+// listJobRequests retrieves the success, unchanged and failed requests made between finit and fend
+// sorted by the time of the request, the backfill requests are not made by the job.
+func (service *CurrencyValueSQLService) listJobRequests(finit, fend time.Time) ([]RequestStatus, error) {
+	rows, err := service.db.Query(`
+		SELECT`+requestStatusColumns+`
+		FROM requests_status
+		WHERE status IN ('success', 'unchanged', 'failure')
+		AND requested_at >= $1::TIMESTAMP
+		AND requested_at <= $2::TIMESTAMP
+		ORDER BY requested_at, id;`, finit, fend)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the requests of the job")
+	}
+	defer rows.Close()
+
+	reqs := make([]RequestStatus, 0)
+
+	for rows.Next() {
+		rs, err := scanRequestStatus(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scanning multiple records")
+		}
+
+		reqs = append(reqs, rs)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to iterate the records")
+	}
+
+	return reqs, nil
+}
+
+// listRequestsCurrencies retrieves the currencies stored by each success request made between finit
+// and fend, the requests without values are not included.
+func (service *CurrencyValueSQLService) listRequestsCurrencies(finit, fend time.Time) (map[int64][]string, error) {
+	rows, err := service.db.Query(`
+		SELECT
+			cv.request_id,
+			cv.name
+		FROM
+			currencies_values cv
+		JOIN
+			requests_status rs ON rs.id = cv.request_id
+		WHERE
+			rs.status = 'success'
+		AND
+			rs.requested_at >= $1::TIMESTAMP
+		AND
+			rs.requested_at <= $2::TIMESTAMP
+		ORDER BY
+			cv.request_id,
+			cv.name;
+	`, finit, fend)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the currencies of the requests")
+	}
+	defer rows.Close()
+
+	values := map[int64][]string{}
+
+	for rows.Next() {
+		var (
+			id   int64
+			name string
+		)
+
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, errors.Wrap(err, "failed to scanning multiple records")
+		}
+
+		values[id] = append(values[id], name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to iterate the records")
+	}
+
+	return values, nil
+}
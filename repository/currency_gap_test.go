@@ -0,0 +1,214 @@
+package repository_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/PacoDw/currency/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// every is a schedule that runs every d.
+type every time.Duration
+
+func (d every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(d))
+}
+
+// scheduleFunc is a schedule that runs at the times returned by the func.
+type scheduleFunc func(t time.Time) time.Time
+
+func (f scheduleFunc) Next(t time.Time) time.Time {
+	return f(t)
+}
+
+// formatGaps formats the gaps to compare them easily.
+func formatGaps(gaps []repository.CurrencyGap) []string {
+	formatted := make([]string, 0, len(gaps))
+
+	for _, g := range gaps {
+		ids := make([]int64, 0, len(g.Failures))
+		for _, rs := range g.Failures {
+			ids = append(ids, rs.ID)
+		}
+
+		formatted = append(formatted, fmt.Sprintf("%s %s-%s duration=%s missing=%d failures=%v",
+			g.Name, g.From.Format("15:04"), g.To.Format("15:04"), g.Duration, g.Missing, ids))
+	}
+
+	return formatted
+}
+
+func TestListCurrencyGaps(t *testing.T) {
+	conn := repository.NewMemoryConnection()
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2022, 10, 19, hour, minute, 0, 0, time.UTC)
+	}
+
+	insert := func(status string, requestedAt time.Time, names ...string) {
+		id, err := conn.RequestStatus.Insert(repository.RequestStatus{Status: status, RequestedAt: requestedAt})
+		assert.Nil(t, err)
+
+		cvs := make([]repository.CurrencyValue, 0, len(names))
+		for _, name := range names {
+			cvs = append(cvs, repository.CurrencyValue{Name: name, RequestID: id, Value: decimal.NewFromInt(1), LastUdatedAt: requestedAt})
+		}
+
+		_, _, err = conn.CurrencyValue.BulkInsert(cvs)
+		assert.Nil(t, err)
+	}
+
+	// hourly requests, the 12:00 and 13:00 requests failed, the 15:00 request did not store EUR, the 16:00
+	// and 20:00 requests did not run and the 18:00 request was late but it is not a gap. The rates were not
+	// updated by the 19:00 and 21:00 requests, they cover the currencies of the 18:20 request
+	insert("success", at(10, 0), "EUR", "MXN")
+	insert("success", at(11, 0), "EUR", "MXN")
+	insert("failure", at(12, 0))
+	insert("failure", at(13, 0))
+	insert("success", at(14, 0), "EUR", "MXN")
+	insert("success", at(15, 0), "MXN")
+	insert("success", at(17, 0), "EUR", "MXN")
+	insert("success", at(18, 20), "EUR", "MXN")
+	insert("unchanged", at(19, 0))
+	insert("unchanged", at(21, 0))
+
+	finit, fend := at(0, 0), at(23, 0)
+
+	t.Run("all", func(t *testing.T) {
+		gaps, err := conn.CurrencyValue.ListCurrencyGaps("all", every(time.Hour), &finit, &fend)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, []string{
+			"EUR 11:00-14:00 duration=3h0m0s missing=2 failures=[3 4]",
+			"EUR 14:00-17:00 duration=3h0m0s missing=2 failures=[]",
+			"EUR 19:00-21:00 duration=2h0m0s missing=1 failures=[]",
+			"MXN 11:00-14:00 duration=3h0m0s missing=2 failures=[3 4]",
+			"MXN 15:00-17:00 duration=2h0m0s missing=1 failures=[]",
+			"MXN 19:00-21:00 duration=2h0m0s missing=1 failures=[]",
+		}, formatGaps(gaps))
+	})
+
+	t.Run("snapshot before the dates", func(t *testing.T) {
+		finit := at(18, 30)
+
+		gaps, err := conn.CurrencyValue.ListCurrencyGaps("EUR", every(time.Hour), &finit, &fend)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, []string{"EUR 19:00-21:00 duration=2h0m0s missing=1 failures=[]"}, formatGaps(gaps))
+	})
+
+	t.Run("currency and dates", func(t *testing.T) {
+		finit, fend := at(12, 30), at(18, 0)
+
+		gaps, err := conn.CurrencyValue.ListCurrencyGaps("MXN", every(time.Hour), &finit, &fend)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, []string{"MXN 15:00-17:00 duration=2h0m0s missing=1 failures=[]"}, formatGaps(gaps))
+	})
+
+	t.Run("longer interval", func(t *testing.T) {
+		gaps, err := conn.CurrencyValue.ListCurrencyGaps("MXN", every(3*time.Hour), nil, nil)
+
+		assert.Nil(t, err)
+		assert.Empty(t, gaps)
+	})
+
+	t.Run("off schedule", func(t *testing.T) {
+		// the job doesn't run at 12:00 and 13:00, so the failed requests are not missing values
+		sched := scheduleFunc(func(t time.Time) time.Time {
+			next := t.Truncate(time.Hour).Add(time.Hour)
+			if next.Hour() == 12 || next.Hour() == 13 {
+				return next.Truncate(24 * time.Hour).Add(14 * time.Hour)
+			}
+
+			return next
+		})
+
+		gaps, err := conn.CurrencyValue.ListCurrencyGaps("all", sched, &finit, &fend)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, []string{
+			"EUR 14:00-17:00 duration=3h0m0s missing=2 failures=[]",
+			"EUR 19:00-21:00 duration=2h0m0s missing=1 failures=[]",
+			"MXN 15:00-17:00 duration=2h0m0s missing=1 failures=[]",
+			"MXN 19:00-21:00 duration=2h0m0s missing=1 failures=[]",
+		}, formatGaps(gaps))
+	})
+
+	t.Run("invalid schedule", func(t *testing.T) {
+		_, err := conn.CurrencyValue.ListCurrencyGaps("MXN", every(0), nil, nil)
+
+		assert.EqualError(t, err, "the schedule never runs")
+		assert.ErrorIs(t, err, repository.ErrInvalidArgument)
+	})
+
+	t.Run("too many runs", func(t *testing.T) {
+		fend := finit.Add(48 * time.Hour)
+
+		_, err := conn.CurrencyValue.ListCurrencyGaps("all", every(time.Second), &finit, &fend)
+
+		assert.EqualError(t, err, "the schedule runs more than 100000 times between finit and fend")
+		assert.ErrorIs(t, err, repository.ErrInvalidArgument)
+	})
+}
+
+func TestSQLListCurrencyGaps(t *testing.T) {
+	TestEnvDBConnectionVariables(t)
+
+	conn := repository.NewSQLConnection(config)
+
+	assert.Condition(t, func() (success bool) { return assert.NotNil(t, conn) })
+
+	// a day far from the stored rates, the 11:00 value is missing because its request failed, the 14:00
+	// request did not run and the 15:00 request found the rates unchanged
+	at := func(hour int) time.Time {
+		return time.Date(2001, 2, 3, hour, 0, 0, 0, time.UTC)
+	}
+
+	for _, hour := range []int{9, 10, 12, 13} {
+		id, err := conn.RequestStatus.Insert(repository.RequestStatus{Status: "success", RequestedAt: at(hour)})
+		assert.Condition(t, func() (success bool) { return assert.Nil(t, err) })
+
+		_, _, err = conn.CurrencyValue.BulkInsert([]repository.CurrencyValue{{Name: "GAP", RequestID: id, Value: decimal.NewFromInt(1), LastUdatedAt: at(hour)}})
+		assert.Condition(t, func() (success bool) { return assert.Nil(t, err) })
+	}
+
+	failed, err := conn.RequestStatus.Insert(repository.RequestStatus{Status: "failure", RequestedAt: at(11)})
+	assert.Condition(t, func() (success bool) { return assert.Nil(t, err) })
+
+	_, err = conn.RequestStatus.Insert(repository.RequestStatus{Status: "unchanged", RequestedAt: at(15)})
+	assert.Condition(t, func() (success bool) { return assert.Nil(t, err) })
+
+	finit, fend := at(0), at(23)
+
+	gaps, err := conn.CurrencyValue.ListCurrencyGaps("GAP", every(time.Hour), &finit, &fend)
+
+	assert.Nil(t, err)
+
+	if assert.Len(t, gaps, 2) {
+		assert.True(t, at(10).Equal(gaps[0].From), gaps[0].From.String())
+		assert.True(t, at(12).Equal(gaps[0].To), gaps[0].To.String())
+		assert.EqualValues(t, "2h0m0s", gaps[0].Duration)
+		assert.EqualValues(t, 1, gaps[0].Missing)
+
+		ids := make([]int64, 0, len(gaps[0].Failures))
+		for _, rs := range gaps[0].Failures {
+			ids = append(ids, rs.ID)
+		}
+
+		assert.Contains(t, ids, failed)
+
+		assert.True(t, at(13).Equal(gaps[1].From), gaps[1].From.String())
+		assert.True(t, at(15).Equal(gaps[1].To), gaps[1].To.String())
+		assert.Empty(t, gaps[1].Failures)
+	}
+
+	// the requests at 11:00 and 14:00 were not expected every two hours
+	gaps, err = conn.CurrencyValue.ListCurrencyGaps("GAP", every(2*time.Hour), &finit, &fend)
+
+	assert.Nil(t, err)
+	assert.Empty(t, gaps)
+}
@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// RollupResult represents the number of raw values rolled up into the hourly aggregates and the number
+// of hourly aggregates rolled up into the daily ones.
+type RollupResult struct {
+	Hourly int64 `json:"hourly"`
+	Daily  int64 `json:"daily"`
+}
+
+// currencyRollup represents a row of the currencies_values_hourly and currencies_values_daily tables, a
+// raw value is a rollup of one value so all the resolutions are merged the same way.
+type currencyRollup struct {
+	name     string
+	bucket   time.Time
+	openedAt time.Time
+	open     decimal.Decimal
+	high     decimal.Decimal
+	low      decimal.Decimal
+	closedAt time.Time
+	close    decimal.Decimal
+	sum      decimal.Decimal
+	count    int64
+}
+
+// newCurrencyRollup creates the rollup of one value, its bucket is the time of the value.
+func newCurrencyRollup(cv CurrencyValue) currencyRollup {
+	return currencyRollup{
+		name:     cv.Name,
+		bucket:   cv.LastUdatedAt,
+		openedAt: cv.LastUdatedAt,
+		open:     cv.Value,
+		high:     cv.Value,
+		low:      cv.Value,
+		closedAt: cv.LastUdatedAt,
+		close:    cv.Value,
+		sum:      cv.Value,
+		count:    1,
+	}
+}
+
+// merge adds the values of o, the ties of open are resolved by the first merged and the ties of close
+// by the last one.
+func (r *currencyRollup) merge(o currencyRollup) {
+	if o.openedAt.Before(r.openedAt) {
+		r.open, r.openedAt = o.open, o.openedAt
+	}
+
+	if !o.closedAt.Before(r.closedAt) {
+		r.close, r.closedAt = o.close, o.closedAt
+	}
+
+	if o.high.GreaterThan(r.high) {
+		r.high = o.high
+	}
+
+	if o.low.LessThan(r.low) {
+		r.low = o.low
+	}
+
+	r.sum = r.sum.Add(o.sum)
+	r.count += o.count
+}
+
+// value returns the rollup as the value of the currency at its close.
+func (r currencyRollup) value() CurrencyValue {
+	return CurrencyValue{Name: r.name, Value: r.close, LastUdatedAt: r.closedAt}
+}
+
+// currencyBucket returns the rollup as an aggregation of its bucket.
+func (r currencyRollup) currencyBucket() CurrencyBucket {
+	return CurrencyBucket{
+		Name:   r.name,
+		Bucket: r.bucket,
+		Open:   r.open,
+		High:   r.high,
+		Low:    r.low,
+		Close:  r.close,
+		Avg:    r.sum.Div(decimal.NewFromInt(r.count)),
+		Count:  r.count,
+	}
+}
+
+// currencySeries selects the raw values and the rollups as a single series of aggregates, each one is
+// filtered by the time of its last value. The raw values keep their id so the ties are resolved like
+// the insertion order.
+const currencySeries = `
+	(
+		SELECT
+			id,
+			name,
+			last_updated_at::TIMESTAMP AS bucket,
+			last_updated_at::TIMESTAMP AS opened_at,
+			value AS open,
+			value AS high,
+			value AS low,
+			last_updated_at::TIMESTAMP AS closed_at,
+			value AS close,
+			value AS sum,
+			1::BIGINT AS count
+		FROM
+			currencies_values
+		UNION ALL
+		SELECT 0, name, bucket, opened_at, open, high, low, closed_at, close, sum, count
+		FROM currencies_values_hourly
+		UNION ALL
+		SELECT 0, name, bucket, opened_at, open, high, low, closed_at, close, sum, count
+		FROM currencies_values_daily
+	) AS series`
+
+// rollupConflict merges the aggregate of a bucket already rolled up, e.g.: a value stored by a backfill
+// after its hour was rolled up.
+const rollupConflict = `
+	ON CONFLICT (name, bucket) DO UPDATE SET
+		opened_at = LEAST(r.opened_at, EXCLUDED.opened_at),
+		open = CASE WHEN EXCLUDED.opened_at < r.opened_at THEN EXCLUDED.open ELSE r.open END,
+		high = GREATEST(r.high, EXCLUDED.high),
+		low = LEAST(r.low, EXCLUDED.low),
+		closed_at = GREATEST(r.closed_at, EXCLUDED.closed_at),
+		close = CASE WHEN EXCLUDED.closed_at >= r.closed_at THEN EXCLUDED.close ELSE r.close END,
+		sum = r.sum + EXCLUDED.sum,
+		count = r.count + EXCLUDED.count
+	RETURNING r.name`
+
+// Rollup moves the raw values updated before rawBefore into the hourly aggregates and the hourly
+// aggregates of the buckets before hourlyBefore into the daily ones, both in the same transaction. The
+// latest value of each currency is kept as a raw value so the latest rates are always available. If
+// hourlyBefore is zero the hourly aggregates are kept. The dates should be at the start of an hour and
+// of a day respectively so a bucket is rolled up at once.
+func (service *CurrencyValueSQLService) Rollup(rawBefore, hourlyBefore time.Time) (RollupResult, error) {
+	var result RollupResult
+
+	tx, err := service.db.Begin()
+	if err != nil {
+		return result, errors.Wrap(err, "could not start a new transaction")
+	}
+
+	if err := tx.QueryRow(`
+		WITH latest AS (
+			SELECT DISTINCT ON (name)
+				id
+			FROM
+				currencies_values
+			ORDER BY
+				name,
+				last_updated_at DESC,
+				id DESC
+		), moved AS (
+			DELETE FROM
+				currencies_values
+			WHERE
+				last_updated_at < $1::TIMESTAMP
+			AND
+				id NOT IN (SELECT id FROM latest)
+			RETURNING
+				id,
+				name,
+				value,
+				last_updated_at
+		), rolled AS (
+			INSERT INTO currencies_values_hourly AS r
+				(name, bucket, opened_at, open, high, low, closed_at, close, sum, count)
+			SELECT
+				name,
+				date_trunc('hour', last_updated_at),
+				MIN(last_updated_at),
+				(ARRAY_AGG(value ORDER BY last_updated_at ASC, id ASC))[1],
+				MAX(value),
+				MIN(value),
+				MAX(last_updated_at),
+				(ARRAY_AGG(value ORDER BY last_updated_at DESC, id DESC))[1],
+				SUM(value),
+				COUNT(*)
+			FROM
+				moved
+			GROUP BY
+				name,
+				date_trunc('hour', last_updated_at)
+			`+rollupConflict+`
+		)
+		SELECT COUNT(*) FROM moved;
+	`, rawBefore).Scan(&result.Hourly); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return result, errors.Wrap(err, "failed to make a rollback")
+		}
+
+		return result, errors.Wrap(err, "failed to roll up the values into the hourly aggregates")
+	}
+
+	if !hourlyBefore.IsZero() {
+		if err := tx.QueryRow(`
+			WITH moved AS (
+				DELETE FROM
+					currencies_values_hourly
+				WHERE
+					bucket < $1::TIMESTAMP
+				RETURNING
+					*
+			), rolled AS (
+				INSERT INTO currencies_values_daily AS r
+					(name, bucket, opened_at, open, high, low, closed_at, close, sum, count)
+				SELECT
+					name,
+					date_trunc('day', bucket),
+					MIN(opened_at),
+					(ARRAY_AGG(open ORDER BY opened_at ASC))[1],
+					MAX(high),
+					MIN(low),
+					MAX(closed_at),
+					(ARRAY_AGG(close ORDER BY closed_at DESC))[1],
+					SUM(sum),
+					SUM(count)
+				FROM
+					moved
+				GROUP BY
+					name,
+					date_trunc('day', bucket)
+				`+rollupConflict+`
+			)
+			SELECT COUNT(*) FROM moved;
+		`, hourlyBefore).Scan(&result.Daily); err != nil {
+			if err := tx.Rollback(); err != nil {
+				return RollupResult{}, errors.Wrap(err, "failed to make a rollback")
+			}
+
+			return RollupResult{}, errors.Wrap(err, "failed to roll up the hourly aggregates into the daily ones")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return RollupResult{}, errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return result, nil
+}
+
+// getRollupCrossRate computes the cross rate with the closes of the latest hourly or daily bucket that
+// contains both currencies, if asOf is set then the bucket must have been closed at or before it.
+func (service *CurrencyValueSQLService) getRollupCrossRate(from, to string, asOf interface{}) (CrossRate, error) {
+	var (
+		fromVal = CurrencyValue{Name: from}
+		toVal   = CurrencyValue{Name: to}
+	)
+
+	err := service.db.QueryRow(`
+		WITH rollups AS (
+			SELECT 'hour' AS resolution, name, bucket, closed_at, close FROM currencies_values_hourly
+			UNION ALL
+			SELECT 'day' AS resolution, name, bucket, closed_at, close FROM currencies_values_daily
+		)
+		SELECT
+			f.close,
+			f.closed_at,
+			t.close,
+			t.closed_at
+		FROM
+			rollups f
+		INNER JOIN
+			rollups t ON t.resolution = f.resolution AND t.bucket = f.bucket AND t.name = $2
+		WHERE
+			f.name = $1
+		AND
+			($3::TIMESTAMP IS NULL OR f.closed_at <= $3::TIMESTAMP)
+		ORDER BY
+			f.closed_at DESC
+		LIMIT 1;
+	`, from, to, asOf).Scan(
+		&fromVal.Value,
+		&fromVal.LastUdatedAt,
+		&toVal.Value,
+		&toVal.LastUdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return CrossRate{}, ErrNotFound
+	}
+
+	if err != nil {
+		return CrossRate{}, errors.Wrap(err, "failed to get the aggregates of the cross rate")
+	}
+
+	return NewCrossRate(fromVal, toVal)
+}
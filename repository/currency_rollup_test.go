@@ -0,0 +1,148 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PacoDw/currency/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRollup(t *testing.T) {
+	conn := repository.NewMemoryConnection()
+
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2022, 10, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	insert := func(updatedAt time.Time, usd, mxn float64) {
+		id, err := conn.RequestStatus.Insert(repository.RequestStatus{Status: "success", RequestedAt: updatedAt})
+		assert.Nil(t, err)
+
+		_, _, err = conn.CurrencyValue.BulkInsert([]repository.CurrencyValue{
+			{Name: "USD", RequestID: id, Value: decimal.NewFromFloat(usd), LastUdatedAt: updatedAt},
+			{Name: "MXN", RequestID: id, Value: decimal.NewFromFloat(mxn), LastUdatedAt: updatedAt},
+		})
+		assert.Nil(t, err)
+	}
+
+	// two hours of the 17th, one hour of the 18th and the latest snapshot of the 19th
+	insert(at(17, 10, 0), 1, 20)
+	insert(at(17, 10, 30), 1, 22)
+	insert(at(17, 11, 15), 1, 21)
+	insert(at(18, 9, 0), 1, 19)
+	insert(at(18, 9, 45), 1, 19.5)
+	insert(at(19, 8, 0), 1, 18)
+
+	before, err := conn.CurrencyValue.ListCurrencyBuckets("MXN", repository.BucketDay, nil, nil)
+	assert.Nil(t, err)
+
+	t.Run("raw values into hours", func(t *testing.T) {
+		result, err := conn.CurrencyValue.Rollup(at(19, 0, 0), time.Time{})
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, repository.RollupResult{Hourly: 10}, result)
+
+		vals, err := conn.CurrencyValue.ListCurrenciesByDateRange("MXN", nil, nil)
+
+		assert.Nil(t, err)
+		assert.Len(t, vals, 4)
+
+		// the hours are listed chronologically by their close
+		assert.EqualValues(t, at(17, 10, 30), vals[0].LastUdatedAt)
+		assert.EqualValues(t, "22", vals[0].Value.String())
+		assert.EqualValues(t, 0, vals[0].RequestID)
+		assert.EqualValues(t, at(18, 9, 45), vals[2].LastUdatedAt)
+		assert.EqualValues(t, 6, vals[3].RequestID, "the latest value is kept raw")
+
+		buckets, err := conn.CurrencyValue.ListCurrencyBuckets("MXN", repository.BucketHour, nil, nil)
+
+		assert.Nil(t, err)
+		assert.Len(t, buckets, 4)
+		assert.EqualValues(t, "20", buckets[0].Open.String())
+		assert.EqualValues(t, "22", buckets[0].Close.String())
+		assert.EqualValues(t, "21", buckets[0].Avg.String())
+		assert.EqualValues(t, 2, buckets[0].Count)
+	})
+
+	t.Run("hours into days", func(t *testing.T) {
+		result, err := conn.CurrencyValue.Rollup(at(19, 0, 0), at(18, 0, 0))
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, repository.RollupResult{Daily: 4}, result)
+
+		// the aggregates don't change with the resolution
+		after, err := conn.CurrencyValue.ListCurrencyBuckets("MXN", repository.BucketDay, nil, nil)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, before, after)
+
+		// a smaller bucket has the size of the rollup
+		buckets, err := conn.CurrencyValue.ListCurrencyBuckets("USD", repository.BucketHour, nil, nil)
+
+		assert.Nil(t, err)
+		assert.Len(t, buckets, 3)
+		assert.EqualValues(t, at(17, 0, 0), buckets[0].Bucket)
+		assert.EqualValues(t, 3, buckets[0].Count)
+
+		finit, fend, err := conn.CurrencyValue.GetFinitAndFend()
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, at(17, 10, 0), finit)
+		assert.EqualValues(t, at(19, 8, 0), fend)
+
+		days, err := conn.CurrencyValue.ListStoredDays(at(17, 0, 0), at(19, 23, 59))
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, []time.Time{at(17, 0, 0), at(18, 0, 0), at(19, 0, 0)}, days)
+	})
+
+	t.Run("latest and cross rate", func(t *testing.T) {
+		vals, err := conn.CurrencyValue.ListLatestCurrencies("MXN")
+
+		assert.Nil(t, err)
+		assert.Len(t, vals, 1)
+		assert.EqualValues(t, at(19, 8, 0), vals[0].LastUdatedAt)
+
+		cr, err := conn.CurrencyValue.GetCrossRate("USD", "MXN", nil)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, 6, cr.RequestID)
+
+		// the closes of the rolled up bucket
+		asOf := at(18, 12, 0)
+
+		cr, err = conn.CurrencyValue.GetCrossRate("USD", "MXN", &asOf)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "19.5", cr.Rate.String())
+		assert.EqualValues(t, 0, cr.RequestID)
+		assert.EqualValues(t, at(18, 9, 45), cr.AsOf)
+
+		asOf = at(17, 9, 0)
+
+		_, err = conn.CurrencyValue.GetCrossRate("USD", "MXN", &asOf)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("merges the late values", func(t *testing.T) {
+		insert(at(18, 23, 0), 1, 30)
+
+		result, err := conn.CurrencyValue.Rollup(at(19, 0, 0), at(19, 0, 0))
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, repository.RollupResult{Hourly: 2, Daily: 4}, result)
+
+		finit, fend := at(18, 0, 0), at(18, 23, 59)
+
+		buckets, err := conn.CurrencyValue.ListCurrencyBuckets("MXN", repository.BucketDay, &finit, &fend)
+
+		assert.Nil(t, err)
+		assert.Len(t, buckets, 1)
+		assert.EqualValues(t, "19", buckets[0].Open.String())
+		assert.EqualValues(t, "30", buckets[0].High.String())
+		assert.EqualValues(t, "30", buckets[0].Close.String())
+		assert.EqualValues(t, 3, buckets[0].Count)
+	})
+}
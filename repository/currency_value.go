@@ -1,18 +1,60 @@
 package repository
 
 import (
+	"database/sql"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 )
 
 // CurrencyValueRepository defines the interface that device must satisfy.
 type CurrencyValueRepository interface {
-	BulkInsert(cvs []CurrencyValue) error
+	BulkInsert(cvs []CurrencyValue) (inserted, skipped int, err error)
 	ListCurrenciesByDateRange(currency string, finit, fend *time.Time) ([]CurrencyValue, error)
 	GetFinitAndFend() (finit, fend time.Time, err error)
+
+	// GetLastInsertedAt gets the last updated date of the latest value inserted, it is zero if there are no
+	// values. The values inserted later are usually the newest ones, but a backfill inserts older values.
+	GetLastInsertedAt() (time.Time, error)
+
+	// GetCrossRate computes the rate between the from and to currencies using the latest snapshot that
+	// contains both of them, if asOf is set then the snapshot must have been updated at or before it.
+	// When the snapshots were rolled up the closes of the latest bucket with both currencies are used and
+	// the rate has no request. If there is no snapshot nor bucket with both currencies it returns ErrNotFound.
+	GetCrossRate(from, to string, asOf *time.Time) (CrossRate, error)
+
+	ListLatestCurrencies(currency string) ([]CurrencyValue, error)
+
+	// ListCurrencyBuckets aggregates the values of the currency, or of each currency when it is 'all',
+	// in time buckets between finit and fend, if one of the dates is not set the first or the last date
+	// stored is used. The result is sorted by the name of the currency and the bucket.
+	// Note: the values rolled up are merged by their hourly or daily aggregates, so a bucket smaller than
+	// the rollup of its range has the size of the rollup.
+	ListCurrencyBuckets(currency string, bucket Bucket, finit, fend *time.Time) ([]CurrencyBucket, error)
+
+	// ListCurrenciesByRequestID retrieves the values captured by the request sorted by the name of the
+	// currency, if the request did not store any value the result is empty.
+	ListCurrenciesByRequestID(requestID int64) ([]CurrencyValue, error)
+
+	// ListStoredDays retrieves the days, as the midnight in UTC, with at least one currency value updated
+	// between finit and fend, both included, the values rolled up are taken by the time of their last
+	// value. The result is sorted by the day.
+	ListStoredDays(finit, fend time.Time) ([]time.Time, error)
+
+	// ListCurrencyGaps compares the requests made to the Currency Provider between finit and fend against
+	// the runs of the schedule and returns the windows of the currency, or of each currency when it is
+	// 'all', without requests. A success request covers the currencies it stored and an unchanged request
+	// covers the currencies of the latest snapshot, the backfill requests are not compared. If finit is not
+	// set the first date stored is used and if fend is not set the current time, the range must not have
+	// more than 100000 runs. Each gap has the failed requests made inside its window and the result is
+	// sorted by the name of the currency and the start of the window.
+	// Note: the values rolled up have no request, so only the raw values are compared.
+	ListCurrencyGaps(currency string, sched Schedule, finit, fend *time.Time) ([]CurrencyGap, error)
+
+	Rollup(rawBefore, hourlyBefore time.Time) (RollupResult, error)
 }
 
 // CurrencyValueSQLService represents a sqlService type.
@@ -23,34 +65,45 @@ type CurrencyValueSQLService sqlService
 // a sqlService type.
 var _ CurrencyValueRepository = &CurrencyValueSQLService{}
 
+// CurrencyValue represents the value of a currency against the base currency of the Currency Provider,
+// the value is an exact decimal that round-trips through JSON as a string and through NUMERIC without
+// losing precision.
 type CurrencyValue struct {
-	Name         string    `json:"name,omitempty"`
-	RequestID    int64     `json:"request_id,omitempty"`
-	Value        float64   `json:"value,omitempty"`
-	LastUdatedAt time.Time `json:"last_updated_at,omitempty"`
+	Name         string          `json:"name,omitempty"`
+	RequestID    int64           `json:"request_id,omitempty"`
+	Provider     string          `json:"provider,omitempty"`
+	Value        decimal.Decimal `json:"value"`
+	LastUdatedAt time.Time       `json:"last_updated_at,omitempty"`
 }
 
-// BulkInsert inserts all currencies values from the Currency provider into the database.
-func (service *CurrencyValueSQLService) BulkInsert(cvs []CurrencyValue) error {
+// BulkInsert inserts all currencies values from the Currency provider into the database, a value
+// with the same name, provider and last_updated_at of a stored one is skipped, so a retried request
+// or several replicas don't store duplicates. It returns the number of values inserted and skipped.
+func (service *CurrencyValueSQLService) BulkInsert(cvs []CurrencyValue) (inserted, skipped int, err error) {
+	if len(cvs) == 0 {
+		return 0, 0, nil
+	}
+
 	var (
 		placeholders = []string{}
 		vals         = []interface{}{}
 	)
 
 	for i := range cvs {
-		placeholders = append(placeholders, fmt.Sprintf("($%d,$%d,$%d,$%d)",
-			i*4+1,
-			i*4+2,
-			i*4+3,
-			i*4+4,
+		placeholders = append(placeholders, fmt.Sprintf("($%d,$%d,$%d,$%d,$%d)",
+			i*5+1,
+			i*5+2,
+			i*5+3,
+			i*5+4,
+			i*5+5,
 		))
 
-		vals = append(vals, cvs[i].Name, cvs[i].RequestID, cvs[i].Value, cvs[i].LastUdatedAt)
+		vals = append(vals, cvs[i].Name, cvs[i].RequestID, cvs[i].Provider, cvs[i].Value, cvs[i].LastUdatedAt)
 	}
 
 	tx, err := service.db.Begin()
 	if err != nil {
-		return errors.Wrap(err, "could not start a new transaction")
+		return 0, 0, errors.Wrap(err, "could not start a new transaction")
 	}
 
 	insertStatement := fmt.Sprintf(`
@@ -58,34 +111,47 @@ func (service *CurrencyValueSQLService) BulkInsert(cvs []CurrencyValue) error {
 			(
 				name,
 				request_id,
+				provider,
 				value,
 				last_updated_at
 			)
 		VALUES 
 			%s
+		ON CONFLICT (name, provider, last_updated_at) DO NOTHING
 	;`, strings.Join(placeholders, ","))
 
-	_, err = tx.Exec(insertStatement, vals...)
+	res, err := tx.Exec(insertStatement, vals...)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, 0, errors.Wrap(err, "failed to make a rollback")
+		}
+
+		return 0, 0, errors.Wrap(err, "failed to insert multiple records at once")
+	}
+
+	rows, err := res.RowsAffected()
 	if err != nil {
 		if err := tx.Rollback(); err != nil {
-			return errors.Wrap(err, "failed to make a rollback")
+			return 0, 0, errors.Wrap(err, "failed to make a rollback")
 		}
 
-		return errors.Wrap(err, "failed to insert multiple records at once")
+		return 0, 0, errors.Wrap(err, "failed to count the inserted records")
 	}
 
 	if err := tx.Commit(); err != nil {
-		return errors.Wrap(err, "failed to commit transaction")
+		return 0, 0, errors.Wrap(err, "failed to commit transaction")
 	}
 
-	return nil
+	return int(rows), len(cvs) - int(rows), nil
 }
 
 // ListCurrenciesByDateRange represents a function to retrieve data with the next parameters:
-// currency => it must be 3 letters and it could be 'all' as a value, it not accepts numbers, it is required
+// currency => it must be from 3 to 10 letters and it could be 'all' as a value, it not accepts numbers, it is required
 // finit    => is a start date is optional
 // fend     => is an end date is optional
-// Note: these paramters are filters.
+// Note: these paramters are filters and all of them are passed as bind parameters. The values rolled up
+// are returned as the close of their hourly or daily bucket without request nor provider, and all the
+// values are sorted chronologically.
 func (service *CurrencyValueSQLService) ListCurrenciesByDateRange(currency string, finit, fend *time.Time) ([]CurrencyValue, error) {
 	if finit == nil || fend == nil || finit.IsZero() || fend.IsZero() {
 		init, end, err := service.GetFinitAndFend()
@@ -102,28 +168,62 @@ func (service *CurrencyValueSQLService) ListCurrenciesByDateRange(currency strin
 		}
 	}
 
-	var stmCond string
+	// a NULL currency means all the currencies
+	var currencyCond interface{}
 	if !strings.EqualFold(currency, "all") {
-		stmCond = fmt.Sprintf("AND name = '%s'", currency)
+		currencyCond = currency
 	}
 
-	rows, err := service.db.Query(fmt.Sprintf(`
+	rows, err := service.db.Query(`
 		SELECT 
 			name,
 			request_id,
+			provider,
 			value,
 			last_updated_at::TIMESTAMP
 		FROM
 			currencies_values
 		WHERE 
-			last_updated_at::TIMESTAMP >= '%s'::TIMESTAMP 
+			last_updated_at::TIMESTAMP >= $1::TIMESTAMP 
 		AND 
-			last_updated_at::TIMESTAMP <='%s'::TIMESTAMP
-		%s;
-`, finit.Format("2006-01-02 15:04:05"), fend.Format("2006-01-02 15:04:05"), stmCond))
+			last_updated_at::TIMESTAMP <= $2::TIMESTAMP
+		AND
+			($3::VARCHAR IS NULL OR name = $3::VARCHAR)
+		UNION ALL
+		SELECT
+			name,
+			0,
+			'',
+			close,
+			closed_at
+		FROM
+			currencies_values_hourly
+		WHERE
+			closed_at BETWEEN $1::TIMESTAMP AND $2::TIMESTAMP
+		AND
+			($3::VARCHAR IS NULL OR name = $3::VARCHAR)
+		UNION ALL
+		SELECT
+			name,
+			0,
+			'',
+			close,
+			closed_at
+		FROM
+			currencies_values_daily
+		WHERE
+			closed_at BETWEEN $1::TIMESTAMP AND $2::TIMESTAMP
+		AND
+			($3::VARCHAR IS NULL OR name = $3::VARCHAR)
+		ORDER BY
+			last_updated_at,
+			name,
+			request_id;
+	`, *finit, *fend, currencyCond)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to range between dates")
 	}
+	defer rows.Close()
 
 	vals := make([]CurrencyValue, 0)
 
@@ -133,6 +233,7 @@ func (service *CurrencyValueSQLService) ListCurrenciesByDateRange(currency strin
 		if err := rows.Scan(
 			&cv.Name,
 			&cv.RequestID,
+			&cv.Provider,
 			&cv.Value,
 			&cv.LastUdatedAt,
 		); err != nil {
@@ -142,45 +243,186 @@ func (service *CurrencyValueSQLService) ListCurrenciesByDateRange(currency strin
 		vals = append(vals, cv)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to iterate the records")
+	}
+
 	return vals, nil
 }
 
-// GetFinitAndFend gets the first date and the last date inserted in the database.
+// GetFinitAndFend gets the first date and the last date inserted in the database, the values rolled up
+// are included. If there are no records both dates are zero.
 func (service *CurrencyValueSQLService) GetFinitAndFend() (finit, fend time.Time, err error) {
-	finit = time.Time{}
-	fend = time.Time{}
+	var first, last sql.NullTime
+
+	if err := service.db.QueryRow(`
+		SELECT
+			MIN(opened_at),
+			MAX(closed_at)
+		FROM`+currencySeries+`;
+	`).Scan(&first, &last); err != nil {
+		return time.Time{}, time.Time{}, errors.Wrap(err, "failed to get the first and the last date of the table")
+	}
+
+	return first.Time, last.Time, nil
+}
+
+// GetLastInsertedAt gets the last updated date of the latest value inserted.
+// Note: the query uses the primary key, so it doesn't scan the table.
+func (service *CurrencyValueSQLService) GetLastInsertedAt() (time.Time, error) {
+	var last sql.NullTime
+
+	err := service.db.QueryRow(`
+		SELECT
+			last_updated_at::TIMESTAMP
+		FROM
+			currencies_values
+		ORDER BY
+			id DESC
+		LIMIT 1;
+	`).Scan(&last)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "failed to get the latest value inserted")
+	}
+
+	return last.Time, nil
+}
+
+// ListLatestCurrencies retrieves the latest value stored of the currency, or of each currency when it
+// is 'all', the result is sorted by the name of the currency.
+// Note: the query uses the currencies_values_name_last_updated_at_idx index.
+func (service *CurrencyValueSQLService) ListLatestCurrencies(currency string) ([]CurrencyValue, error) {
+	// a NULL currency means all the currencies
+	var currencyCond interface{}
+	if !strings.EqualFold(currency, "all") {
+		currencyCond = currency
+	}
 
 	rows, err := service.db.Query(`
-		SELECT last_updated_at::TIMESTAMP
-		FROM (
-			SELECT last_updated_at::TIMESTAMP,
-				ROW_NUMBER() OVER (ORDER BY last_updated_at::TIMESTAMP DESC) AS rn,
-				COUNT(*) OVER () AS total_count
-			FROM currencies_values
-		) t
-		WHERE rn = 1
-		OR rn = total_count
-		ORDER BY last_updated_at::TIMESTAMP DESC;
-	`)
+		SELECT DISTINCT ON (name)
+			name,
+			request_id,
+			provider,
+			value,
+			last_updated_at
+		FROM
+			currencies_values
+		WHERE
+			($1::VARCHAR IS NULL OR name = $1::VARCHAR)
+		ORDER BY
+			name,
+			last_updated_at DESC,
+			id DESC;
+	`, currencyCond)
 	if err != nil {
-		return time.Time{}, time.Time{}, errors.Wrap(err, "failed to get the first and the last date of the table")
+		return nil, errors.Wrap(err, "failed to get the latest currencies")
 	}
+	defer rows.Close()
 
-	rows.Next()
+	vals := make([]CurrencyValue, 0)
+
+	for rows.Next() {
+		var cv CurrencyValue
 
-	if err := rows.Scan(&fend); err != nil {
-		return time.Time{}, time.Time{}, errors.Wrap(err, "failed to scan attributes")
+		if err := rows.Scan(
+			&cv.Name,
+			&cv.RequestID,
+			&cv.Provider,
+			&cv.Value,
+			&cv.LastUdatedAt,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scanning multiple records")
+		}
+
+		vals = append(vals, cv)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to iterate the records")
+	}
+
+	return vals, nil
+}
+
+// ListCurrenciesByRequestID retrieves the values captured by the request.
+func (service *CurrencyValueSQLService) ListCurrenciesByRequestID(requestID int64) ([]CurrencyValue, error) {
+	rows, err := service.db.Query(`
+		SELECT
+			name,
+			request_id,
+			provider,
+			value,
+			last_updated_at::TIMESTAMP
+		FROM
+			currencies_values
+		WHERE
+			request_id = $1
+		ORDER BY name;
+	`, requestID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the currencies of the request")
+	}
+	defer rows.Close()
+
+	vals := make([]CurrencyValue, 0)
+
+	for rows.Next() {
+		var cv CurrencyValue
+
+		if err := rows.Scan(
+			&cv.Name,
+			&cv.RequestID,
+			&cv.Provider,
+			&cv.Value,
+			&cv.LastUdatedAt,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scanning multiple records")
+		}
+
+		vals = append(vals, cv)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to iterate the records")
 	}
 
-	rows.Next()
+	return vals, nil
+}
+
+// ListStoredDays retrieves the days with values between finit and fend.
+func (service *CurrencyValueSQLService) ListStoredDays(finit, fend time.Time) ([]time.Time, error) {
+	rows, err := service.db.Query(`
+		SELECT DISTINCT
+			date_trunc('day', closed_at)::TIMESTAMP AS day
+		FROM`+currencySeries+`
+		WHERE
+			closed_at BETWEEN $1 AND $2
+		ORDER BY day;
+	`, finit.UTC(), fend.UTC())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the stored days")
+	}
+	defer rows.Close()
+
+	days := make([]time.Time, 0)
+
+	for rows.Next() {
+		var day time.Time
+
+		if err := rows.Scan(&day); err != nil {
+			return nil, errors.Wrap(err, "failed to scanning multiple records")
+		}
 
-	if err := rows.Scan(&finit); err != nil {
-		return time.Time{}, time.Time{}, errors.Wrap(err, "failed to scan attributes")
+		days = append(days, day.UTC())
 	}
 
 	if err := rows.Err(); err != nil {
-		return time.Time{}, time.Time{}, err
+		return nil, errors.Wrap(err, "failed to iterate the records")
 	}
 
-	return
+	return days, nil
 }
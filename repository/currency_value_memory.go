@@ -0,0 +1,476 @@
+package repository
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CurrencyValueMemoryService represents a memoryService type.
+type CurrencyValueMemoryService memoryService
+
+// CurrencyValueMemoryService validate if it satisfy the own interface.
+var _ CurrencyValueRepository = &CurrencyValueMemoryService{}
+
+// BulkInsert inserts all currencies values at once, if one of them references a request that
+// doesn't exist none of them is inserted. A value with the same name, provider and last updated date
+// of a stored one is skipped like the unique index of the currencies_values table.
+func (service *CurrencyValueMemoryService) BulkInsert(cvs []CurrencyValue) (inserted, skipped int, err error) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	for i := range cvs {
+		if !(*memoryService)(service).requestExists(cvs[i].RequestID) {
+			return 0, 0, errors.Errorf("failed to insert multiple records at once: the request (%d) does not exist", cvs[i].RequestID)
+		}
+	}
+
+	for _, cv := range cvs {
+		k := currencyKey{cv.Name, cv.Provider, cv.LastUdatedAt.UnixNano()}
+		if service.currencyKeys[k] {
+			skipped++
+
+			continue
+		}
+
+		service.currencyKeys[k] = true
+		service.currenciesValues = append(service.currenciesValues, cv)
+		inserted++
+	}
+
+	return inserted, skipped, nil
+}
+
+// ListCurrenciesByDateRange retrieves the values of the currency, or all of them when it is 'all',
+// between finit and fend, if one of the dates is not set the first or the last date stored is used.
+// The values rolled up are returned as the close of their hourly or daily bucket.
+func (service *CurrencyValueMemoryService) ListCurrenciesByDateRange(currency string, finit, fend *time.Time) ([]CurrencyValue, error) {
+	finit, fend, err := service.dateRange(finit, fend)
+	if err != nil {
+		return nil, err
+	}
+
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+
+	var (
+		all  = strings.EqualFold(currency, "all")
+		vals = service.rawValues(currency, *finit, *fend)
+	)
+
+	for _, r := range (*memoryService)(service).rollups() {
+		if r.closedAt.Before(*finit) || r.closedAt.After(*fend) || (!all && r.name != currency) {
+			continue
+		}
+
+		vals = append(vals, r.value())
+	}
+
+	// chronologically like the SQL backend, the ties keep the raw values first in insertion order
+	sort.SliceStable(vals, func(i, j int) bool {
+		if !vals[i].LastUdatedAt.Equal(vals[j].LastUdatedAt) {
+			return vals[i].LastUdatedAt.Before(vals[j].LastUdatedAt)
+		}
+
+		return vals[i].Name < vals[j].Name
+	})
+
+	return vals, nil
+}
+
+// dateRange returns finit and fend, if one of the dates is not set the first or the last date stored
+// is used.
+func (service *CurrencyValueMemoryService) dateRange(finit, fend *time.Time) (*time.Time, *time.Time, error) {
+	if finit == nil || fend == nil || finit.IsZero() || fend.IsZero() {
+		init, end, err := service.GetFinitAndFend()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if finit == nil || finit.IsZero() {
+			finit = &init
+		}
+
+		if fend == nil || fend.IsZero() {
+			fend = &end
+		}
+	}
+
+	return finit, fend, nil
+}
+
+// rawValues retrieves the values not rolled up of the currency, or all of them when it is 'all',
+// between finit and fend in insertion order. It must be called holding the lock.
+func (service *CurrencyValueMemoryService) rawValues(currency string, finit, fend time.Time) []CurrencyValue {
+	all := strings.EqualFold(currency, "all")
+	vals := make([]CurrencyValue, 0)
+
+	for _, cv := range service.currenciesValues {
+		if cv.LastUdatedAt.Before(finit) || cv.LastUdatedAt.After(fend) {
+			continue
+		}
+
+		if !all && cv.Name != currency {
+			continue
+		}
+
+		vals = append(vals, cv)
+	}
+
+	return vals
+}
+
+// GetFinitAndFend gets the first date and the last date inserted, the values rolled up are included.
+// If there are no values both dates are zero.
+func (service *CurrencyValueMemoryService) GetFinitAndFend() (finit, fend time.Time, err error) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+
+	for _, r := range service.series() {
+		if finit.IsZero() || r.openedAt.Before(finit) {
+			finit = r.openedAt
+		}
+
+		if fend.IsZero() || r.closedAt.After(fend) {
+			fend = r.closedAt
+		}
+	}
+
+	return finit, fend, nil
+}
+
+// GetLastInsertedAt gets the last updated date of the latest value inserted.
+func (service *CurrencyValueMemoryService) GetLastInsertedAt() (time.Time, error) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+
+	if len(service.currenciesValues) == 0 {
+		return time.Time{}, nil
+	}
+
+	return service.currenciesValues[len(service.currenciesValues)-1].LastUdatedAt, nil
+}
+
+// series returns the values not rolled up in insertion order and then the rollups as a single series
+// of aggregates. It must be called holding the lock.
+func (service *CurrencyValueMemoryService) series() []currencyRollup {
+	series := make([]currencyRollup, 0, len(service.currenciesValues))
+
+	for _, cv := range service.currenciesValues {
+		series = append(series, newCurrencyRollup(cv))
+	}
+
+	return append(series, (*memoryService)(service).rollups()...)
+}
+
+// GetCrossRate computes the rate of the latest snapshot, or bucket, with both currencies.
+func (service *CurrencyValueMemoryService) GetCrossRate(from, to string, asOf *time.Time) (CrossRate, error) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+
+	var (
+		found          bool
+		fromVal, toVal CurrencyValue
+	)
+
+	for _, f := range service.currenciesValues {
+		if f.Name != from || (asOf != nil && !asOf.IsZero() && f.LastUdatedAt.After(*asOf)) {
+			continue
+		}
+
+		// keep the latest snapshot, the ties are resolved by the highest request
+		if found && (f.LastUdatedAt.Before(fromVal.LastUdatedAt) ||
+			(f.LastUdatedAt.Equal(fromVal.LastUdatedAt) && f.RequestID <= fromVal.RequestID)) {
+			continue
+		}
+
+		for _, t := range service.currenciesValues {
+			if t.Name == to && t.RequestID == f.RequestID {
+				found, fromVal, toVal = true, f, t
+
+				break
+			}
+		}
+	}
+
+	if !found {
+		return service.getRollupCrossRate(from, to, asOf)
+	}
+
+	return NewCrossRate(fromVal, toVal)
+}
+
+// getRollupCrossRate computes the cross rate with the closes of the latest hourly or daily bucket that
+// contains both currencies, if asOf is set then the bucket must have been closed at or before it. It
+// must be called holding the lock.
+func (service *CurrencyValueMemoryService) getRollupCrossRate(from, to string, asOf *time.Time) (CrossRate, error) {
+	var (
+		found          bool
+		fromVal, toVal CurrencyValue
+	)
+
+	for _, rollups := range []map[rollupKey]*currencyRollup{service.currenciesHourly, service.currenciesDaily} {
+		for k, f := range rollups {
+			if f.name != from || (asOf != nil && !asOf.IsZero() && f.closedAt.After(*asOf)) {
+				continue
+			}
+
+			if found && !f.closedAt.After(fromVal.LastUdatedAt) {
+				continue
+			}
+
+			if t, ok := rollups[rollupKey{to, k.bucket}]; ok {
+				found, fromVal, toVal = true, f.value(), t.value()
+			}
+		}
+	}
+
+	if !found {
+		return CrossRate{}, ErrNotFound
+	}
+
+	return NewCrossRate(fromVal, toVal)
+}
+
+// ListLatestCurrencies retrieves the latest value stored of the currency, or of each currency when it
+// is 'all', the result is sorted by the name of the currency.
+func (service *CurrencyValueMemoryService) ListLatestCurrencies(currency string) ([]CurrencyValue, error) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+
+	var (
+		all    = strings.EqualFold(currency, "all")
+		latest = map[string]CurrencyValue{}
+	)
+
+	// the values are stored in insertion order, so the ties are resolved by the last one inserted
+	for _, cv := range service.currenciesValues {
+		if !all && cv.Name != currency {
+			continue
+		}
+
+		if l, ok := latest[cv.Name]; ok && cv.LastUdatedAt.Before(l.LastUdatedAt) {
+			continue
+		}
+
+		latest[cv.Name] = cv
+	}
+
+	vals := make([]CurrencyValue, 0, len(latest))
+
+	for _, cv := range latest {
+		vals = append(vals, cv)
+	}
+
+	sort.Slice(vals, func(i, j int) bool {
+		return vals[i].Name < vals[j].Name
+	})
+
+	return vals, nil
+}
+
+// ListCurrencyBuckets aggregates the values and their rollups in time buckets.
+func (service *CurrencyValueMemoryService) ListCurrencyBuckets(currency string, bucket Bucket, finit, fend *time.Time) ([]CurrencyBucket, error) {
+	if !bucket.Valid() {
+		return nil, invalidArgumentf("the bucket (%s) is not supported", bucket)
+	}
+
+	finit, fend, err := service.dateRange(finit, fend)
+	if err != nil {
+		return nil, err
+	}
+
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+
+	var (
+		all   = strings.EqualFold(currency, "all")
+		byKey = map[rollupKey]*currencyRollup{}
+	)
+
+	// the values are in insertion order, so the ties of open and close are resolved like the id
+	for _, r := range service.series() {
+		if r.closedAt.Before(*finit) || r.closedAt.After(*fend) || (!all && r.name != currency) {
+			continue
+		}
+
+		rollUp(byKey, r, bucket)
+	}
+
+	buckets := make([]CurrencyBucket, 0, len(byKey))
+
+	for _, r := range byKey {
+		buckets = append(buckets, r.currencyBucket())
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		if buckets[i].Name != buckets[j].Name {
+			return buckets[i].Name < buckets[j].Name
+		}
+
+		return buckets[i].Bucket.Before(buckets[j].Bucket)
+	})
+
+	return buckets, nil
+}
+
+// ListCurrenciesByRequestID retrieves the values captured by the request.
+func (service *CurrencyValueMemoryService) ListCurrenciesByRequestID(requestID int64) ([]CurrencyValue, error) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+
+	vals := make([]CurrencyValue, 0)
+
+	for _, cv := range service.currenciesValues {
+		if cv.RequestID == requestID {
+			vals = append(vals, cv)
+		}
+	}
+
+	sort.SliceStable(vals, func(i, j int) bool {
+		return vals[i].Name < vals[j].Name
+	})
+
+	return vals, nil
+}
+
+// ListStoredDays retrieves the days with values between finit and fend.
+func (service *CurrencyValueMemoryService) ListStoredDays(finit, fend time.Time) ([]time.Time, error) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+
+	seen := map[time.Time]bool{}
+	days := make([]time.Time, 0)
+
+	for _, r := range service.series() {
+		if r.closedAt.Before(finit) || r.closedAt.After(fend) {
+			continue
+		}
+
+		day := r.closedAt.UTC().Truncate(24 * time.Hour)
+		if seen[day] {
+			continue
+		}
+
+		seen[day] = true
+		days = append(days, day)
+	}
+
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Before(days[j])
+	})
+
+	return days, nil
+}
+
+// ListCurrencyGaps compares the requests against the runs of the schedule.
+func (service *CurrencyValueMemoryService) ListCurrencyGaps(currency string, sched Schedule, finit, fend *time.Time) ([]CurrencyGap, error) {
+	if err := validateSchedule(sched); err != nil {
+		return nil, err
+	}
+
+	from, to, err := gapDates(finit, fend, service.GetFinitAndFend)
+	if err != nil {
+		return nil, err
+	}
+
+	if from.IsZero() {
+		return make([]CurrencyGap, 0), nil
+	}
+
+	runs, err := scheduleRuns(sched, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+
+	// the currencies stored by each request, the values rolled up have no request
+	values := map[int64][]string{}
+
+	for _, cv := range service.currenciesValues {
+		values[cv.RequestID] = append(values[cv.RequestID], cv.Name)
+	}
+
+	var (
+		stored   []string
+		storedAt time.Time
+		reqs     = make([]RequestStatus, 0)
+	)
+
+	for _, rs := range service.requestsStatus {
+		switch {
+		case rs.RequestedAt.Before(from):
+			// the currencies of the latest success request with values made before the range
+			if names, ok := values[rs.id]; ok && rs.Status == "success" && !rs.RequestedAt.Before(storedAt) {
+				stored, storedAt = names, rs.RequestedAt
+			}
+		case rs.RequestedAt.After(to):
+		case rs.Status == "success", rs.Status == "unchanged", rs.Status == "failure":
+			reqs = append(reqs, rs.RequestStatus)
+		}
+	}
+
+	// the requests are stored by id, so the ties keep the order of the ids like the SQL backend
+	sort.SliceStable(reqs, func(i, j int) bool {
+		return reqs[i].RequestedAt.Before(reqs[j].RequestedAt)
+	})
+
+	return listGaps(currency, runs, stored, reqs, values), nil
+}
+
+// Rollup moves the values updated before rawBefore into the hourly rollups and the hourly rollups of the
+// buckets before hourlyBefore into the daily ones. The latest value of each currency is kept, if
+// hourlyBefore is zero the hourly rollups are kept.
+func (service *CurrencyValueMemoryService) Rollup(rawBefore, hourlyBefore time.Time) (RollupResult, error) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	var result RollupResult
+
+	// the values are stored in insertion order, so the ties are resolved by the last one inserted
+	latest := map[string]int{}
+
+	for i, cv := range service.currenciesValues {
+		if l, ok := latest[cv.Name]; ok && cv.LastUdatedAt.Before(service.currenciesValues[l].LastUdatedAt) {
+			continue
+		}
+
+		latest[cv.Name] = i
+	}
+
+	kept := make([]CurrencyValue, 0, len(service.currenciesValues))
+
+	for i, cv := range service.currenciesValues {
+		if !cv.LastUdatedAt.Before(rawBefore) || latest[cv.Name] == i {
+			kept = append(kept, cv)
+
+			continue
+		}
+
+		rollUp(service.currenciesHourly, newCurrencyRollup(cv), BucketHour)
+		delete(service.currencyKeys, currencyKey{cv.Name, cv.Provider, cv.LastUdatedAt.UnixNano()})
+		result.Hourly++
+	}
+
+	service.currenciesValues = kept
+
+	if hourlyBefore.IsZero() {
+		return result, nil
+	}
+
+	for k, r := range service.currenciesHourly {
+		if !r.bucket.Before(hourlyBefore) {
+			continue
+		}
+
+		rollUp(service.currenciesDaily, *r, BucketDay)
+		delete(service.currenciesHourly, k)
+		result.Daily++
+	}
+
+	return result, nil
+}
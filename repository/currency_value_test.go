@@ -8,7 +8,7 @@ import (
 
 	"github.com/PacoDw/currency/repository"
 	"github.com/jackc/fake"
-	"github.com/spf13/cast"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -21,40 +21,48 @@ func TestBulkInsertCurrencyValue(t *testing.T) {
 
 	req := []repository.CurrencyValue{
 		{
-			fake.CurrencyCode(),
-			1,
-			cast.ToFloat64(fake.Latitute()),
-			time.Date(2022, 10, 17, 17, 23, 34, 123, time.UTC),
+			Name:         fake.CurrencyCode(),
+			RequestID:    1,
+			Value:        decimal.NewFromFloat32(fake.Latitute()),
+			LastUdatedAt: time.Date(2022, 10, 17, 17, 23, 34, 123, time.UTC),
 		},
 		{
-			fake.CurrencyCode(),
-			1,
-			cast.ToFloat64(fake.Latitute()),
-			time.Date(2022, 10, 16, 17, 23, 34, 123, time.UTC),
+			Name:         fake.CurrencyCode(),
+			RequestID:    1,
+			Value:        decimal.NewFromFloat32(fake.Latitute()),
+			LastUdatedAt: time.Date(2022, 10, 16, 17, 23, 34, 123, time.UTC),
 		},
 		{
-			fake.CurrencyCode(),
-			2,
-			cast.ToFloat64(fake.Latitute()),
-			time.Date(2022, 10, 15, 17, 23, 34, 123, time.UTC),
+			Name:         fake.CurrencyCode(),
+			RequestID:    2,
+			Value:        decimal.NewFromFloat32(fake.Latitute()),
+			LastUdatedAt: time.Date(2022, 10, 15, 17, 23, 34, 123, time.UTC),
 		},
 		{
-			fake.CurrencyCode(),
-			3,
-			cast.ToFloat64(fake.Latitute()),
-			time.Date(2022, 10, 13, 17, 23, 34, 123, time.UTC),
+			Name:         fake.CurrencyCode(),
+			RequestID:    3,
+			Value:        decimal.NewFromFloat32(fake.Latitute()),
+			LastUdatedAt: time.Date(2022, 10, 13, 17, 23, 34, 123, time.UTC),
 		},
 		{
-			fake.CurrencyCode(),
-			3,
-			cast.ToFloat64(fake.Latitute()),
-			time.Date(2022, 10, 10, 17, 23, 34, 123, time.UTC),
+			Name:         fake.CurrencyCode(),
+			RequestID:    3,
+			Value:        decimal.NewFromFloat32(fake.Latitute()),
+			LastUdatedAt: time.Date(2022, 10, 10, 17, 23, 34, 123, time.UTC),
 		},
 	}
 
-	err := conn.CurrencyValue.BulkInsert(req)
+	inserted, skipped, err := conn.CurrencyValue.BulkInsert(req)
 
 	assert.Condition(t, func() (success bool) { return assert.Nil(t, err) })
+	assert.EqualValues(t, len(req), inserted+skipped)
+
+	// inserting the same values again skips all of them
+	inserted, skipped, err = conn.CurrencyValue.BulkInsert(req)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 0, inserted)
+	assert.EqualValues(t, len(req), skipped)
 }
 
 func TestGetFinitAndFend(t *testing.T) {
@@ -89,3 +97,93 @@ func TestListCurrenciesByDateRange(t *testing.T) {
 
 	log.Printf("b: %s\n", b)
 }
+
+func TestHostileCurrencyCodes(t *testing.T) {
+	TestEnvDBConnectionVariables(t)
+
+	conn := repository.NewSQLConnection(config)
+
+	assert.Condition(t, func() (success bool) { return assert.NotNil(t, conn) })
+
+	init := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2022, 10, 31, 0, 0, 0, 0, time.UTC)
+
+	hostiles := []string{
+		"' OR '1'='1",
+		"MXN' OR 'a'='a",
+		"MXN'; DROP TABLE currencies_values; --",
+		"MXN'; DELETE FROM requests_status; --",
+		"' UNION SELECT name, 1, 1, NOW() FROM currencies_values --",
+		"all' --",
+		`\'; SELECT pg_sleep(5); --`,
+		"ÑÑÑ",
+	}
+
+	for i := range hostiles {
+		currency := hostiles[i]
+
+		t.Run(currency, func(t *testing.T) {
+			res, err := conn.CurrencyValue.ListCurrenciesByDateRange(currency, &init, &end)
+
+			assert.Condition(t, func() (success bool) { return assert.Nil(t, err) })
+			assert.Empty(t, res)
+
+			_, err = conn.CurrencyValue.GetCrossRate(currency, "MXN", nil)
+			assert.ErrorIs(t, err, repository.ErrNotFound)
+
+			_, err = conn.CurrencyValue.GetCrossRate("MXN", currency, &end)
+			assert.ErrorIs(t, err, repository.ErrNotFound)
+		})
+	}
+
+	// the tables must still exist after all the hostile codes
+	_, _, err := conn.CurrencyValue.GetFinitAndFend()
+	assert.Nil(t, err)
+
+	_, err = conn.RequestStatus.Insert(repository.RequestStatus{Status: "success", RequestedAt: time.Now()})
+	assert.Nil(t, err)
+}
+
+func TestListLatestCurrencies(t *testing.T) {
+	TestEnvDBConnectionVariables(t)
+
+	conn := repository.NewSQLConnection(config)
+
+	assert.Condition(t, func() (success bool) { return assert.NotNil(t, conn) })
+
+	res, err := conn.CurrencyValue.ListLatestCurrencies("all")
+
+	assert.Condition(t, func() (success bool) { return assert.Nil(t, err) })
+
+	names := map[string]bool{}
+
+	for i := range res {
+		assert.False(t, names[res[i].Name], "the currency (%s) must be returned once", res[i].Name)
+
+		names[res[i].Name] = true
+	}
+}
+
+func TestCurrencyValueJSON(t *testing.T) {
+	cv := repository.CurrencyValue{
+		Name:         "BTC",
+		RequestID:    1,
+		Value:        decimal.RequireFromString("0.000051234567890123456789"),
+		LastUdatedAt: time.Date(2022, 10, 17, 17, 23, 34, 0, time.UTC),
+	}
+
+	blob, err := json.Marshal(cv)
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"name": "BTC",
+		"request_id": 1,
+		"value": "0.000051234567890123456789",
+		"last_updated_at": "2022-10-17T17:23:34Z"
+	}`, string(blob))
+
+	var got repository.CurrencyValue
+
+	assert.Nil(t, json.Unmarshal(blob, &got))
+	assert.True(t, cv.Value.Equal(got.Value))
+}
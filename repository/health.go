@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// HealthChecker defines the interface that a connection must satisfy to be checked by the health
+// endpoints of the server.
+type HealthChecker interface {
+	Ping(ctx context.Context) error
+	Stats() sql.DBStats
+}
+
+// SQLConnection and MemoryConnection validate if they satisfy the interface.
+var (
+	_ HealthChecker = &SQLConnection{}
+	_ HealthChecker = &MemoryConnection{}
+)
+
+// Ping verifies the connection to the database is still alive.
+func (conn *SQLConnection) Ping(ctx context.Context) error {
+	return conn.sqlService.db.PingContext(ctx)
+}
+
+// Stats returns the statistics of the connection pool.
+func (conn *SQLConnection) Stats() sql.DBStats {
+	return conn.sqlService.db.Stats()
+}
+
+// Ping always succeeds because the data lives in the process.
+func (conn *MemoryConnection) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Stats returns empty statistics because there is no connection pool.
+func (conn *MemoryConnection) Stats() sql.DBStats {
+	return sql.DBStats{}
+}
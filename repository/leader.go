@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// leaderLockKey is the key of the advisory lock held by the replica which makes the requests to the
+// Currency Provider.
+const leaderLockKey = 4217002
+
+// Locker defines the interface that a connection must satisfy to elect the leader among the replicas
+// of the server, only the replica holding the lock is the leader.
+type Locker interface {
+	// TryLock takes the lock without waiting, it returns true when the lock is held by this connection
+	// even if it was taken before.
+	TryLock(ctx context.Context) (bool, error)
+
+	// Unlock releases the lock if it is held by this connection.
+	Unlock(ctx context.Context) error
+}
+
+// SQLConnection and MemoryConnection validate if they satisfy the interface.
+var (
+	_ Locker = &SQLConnection{}
+	_ Locker = &MemoryConnection{}
+)
+
+// leaderLock holds the connection of the session which took the advisory lock, the lock lives as
+// long as the session, so when the replica dies postgres closes the session and releases the lock.
+type leaderLock struct {
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+// TryLock takes a session advisory lock through a dedicated connection of the pool. When the lock
+// is already held the session is checked, a lost session means the lock was released too, so it is
+// taken again if no other replica did it first.
+func (conn *SQLConnection) TryLock(ctx context.Context) (bool, error) {
+	conn.leaderLock.mu.Lock()
+	defer conn.leaderLock.mu.Unlock()
+
+	if conn.leaderLock.conn != nil {
+		if err := conn.leaderLock.conn.PingContext(ctx); err == nil {
+			return true, nil
+		}
+
+		conn.leaderLock.conn.Close()
+		conn.leaderLock.conn = nil
+	}
+
+	c, err := conn.sqlService.db.Conn(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get a connection to take the leader lock")
+	}
+
+	var locked bool
+
+	if err := c.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1);`, leaderLockKey).Scan(&locked); err != nil {
+		c.Close()
+
+		return false, errors.Wrap(err, "failed to take the leader lock")
+	}
+
+	if !locked {
+		c.Close()
+
+		return false, nil
+	}
+
+	conn.leaderLock.conn = c
+
+	return true, nil
+}
+
+// Unlock releases the advisory lock and returns the connection to the pool, the lock must be
+// released explicitly because the session is not closed by the pool.
+func (conn *SQLConnection) Unlock(ctx context.Context) error {
+	conn.leaderLock.mu.Lock()
+	defer conn.leaderLock.mu.Unlock()
+
+	if conn.leaderLock.conn == nil {
+		return nil
+	}
+
+	defer func() {
+		conn.leaderLock.conn.Close()
+		conn.leaderLock.conn = nil
+	}()
+
+	if _, err := conn.leaderLock.conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1);`, leaderLockKey); err != nil {
+		return errors.Wrap(err, "failed to release the leader lock")
+	}
+
+	return nil
+}
+
+// TryLock always takes the lock because the data lives in the process, so there is only one replica.
+func (conn *MemoryConnection) TryLock(ctx context.Context) (bool, error) {
+	return true, nil
+}
+
+// Unlock does nothing because the lock is always held.
+func (conn *MemoryConnection) Unlock(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+)
+
+// memoryService represents a type for each in-memory service created, it is the in-memory
+// counterpart of sqlService so all the services share the same data.
+type memoryService struct {
+	mu sync.RWMutex
+
+	requestsStatus   []memoryRequestStatus
+	currenciesValues []CurrencyValue
+
+	// currencyKeys mirrors the unique index of the currencies_values table
+	currencyKeys map[currencyKey]bool
+
+	// currenciesHourly and currenciesDaily mirror the tables of the rollups
+	currenciesHourly map[rollupKey]*currencyRollup
+	currenciesDaily  map[rollupKey]*currencyRollup
+}
+
+// currencyKey represents the unique key of a currency value, the time is in nanoseconds so the same
+// instant in two locations is the same key.
+type currencyKey struct {
+	name      string
+	provider  string
+	updatedAt int64
+}
+
+// rollupKey represents the primary key of a rollup, the time is in nanoseconds like currencyKey.
+type rollupKey struct {
+	name   string
+	bucket int64
+}
+
+// memoryRequestStatus represents a row of the requests_status table.
+type memoryRequestStatus struct {
+	id int64
+
+	RequestStatus
+}
+
+// requestExists checks if the request status id has been inserted, like the foreign key of the
+// currencies_values table. It must be called holding the lock.
+func (ms *memoryService) requestExists(id int64) bool {
+	return id > 0 && id <= int64(len(ms.requestsStatus))
+}
+
+// rollUp merges the rollup into its bucket of the rollups, the buckets are in UTC like the timestamps of
+// the tables. It must be called holding the lock.
+func rollUp(rollups map[rollupKey]*currencyRollup, r currencyRollup, bucket Bucket) {
+	r.bucket = bucket.Truncate(r.bucket.UTC())
+
+	k := rollupKey{r.name, r.bucket.UnixNano()}
+	if stored, ok := rollups[k]; ok {
+		stored.merge(r)
+
+		return
+	}
+
+	rollups[k] = &r
+}
+
+// rollups returns the hourly rollups and then the daily ones, each resolution is sorted by the bucket
+// and the name. It must be called holding the lock.
+func (ms *memoryService) rollups() []currencyRollup {
+	rollups := make([]currencyRollup, 0, len(ms.currenciesHourly)+len(ms.currenciesDaily))
+
+	for _, stored := range []map[rollupKey]*currencyRollup{ms.currenciesHourly, ms.currenciesDaily} {
+		n := len(rollups)
+
+		for _, r := range stored {
+			rollups = append(rollups, *r)
+		}
+
+		resolution := rollups[n:]
+
+		sort.Slice(resolution, func(i, j int) bool {
+			if !resolution[i].bucket.Equal(resolution[j].bucket) {
+				return resolution[i].bucket.Before(resolution[j].bucket)
+			}
+
+			return resolution[i].name < resolution[j].name
+		})
+	}
+
+	return rollups
+}
+
+// MemoryConnection represents the in-memory backend that contains all the services created, it has
+// the same semantics of SQLConnection but the data lives only during the life of the process, so it
+// is useful for tests and local runs.
+// Note: If you has been created a new service it must be listed in this struct.
+type MemoryConnection struct {
+	memoryService *memoryService
+	RequestStatus RequestStatusRepository
+	CurrencyValue CurrencyValueRepository
+}
+
+// NewMemoryConnection creates a new MemoryConnection with all the services in it.
+// Note: If you has been created a new service it must be listed in this struct.
+func NewMemoryConnection() *MemoryConnection {
+	ms := &memoryService{
+		currencyKeys:     map[currencyKey]bool{},
+		currenciesHourly: map[rollupKey]*currencyRollup{},
+		currenciesDaily:  map[rollupKey]*currencyRollup{},
+	}
+
+	return &MemoryConnection{
+		memoryService: ms,
+		RequestStatus: (*RequestStatusMemoryService)(ms),
+		CurrencyValue: (*CurrencyValueMemoryService)(ms),
+	}
+}
@@ -0,0 +1,226 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PacoDw/currency/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryConnection(t *testing.T) {
+	conn := repository.NewMemoryConnection()
+
+	first := time.Date(2022, 10, 17, 17, 23, 34, 0, time.UTC)
+	second := time.Date(2022, 10, 18, 17, 23, 34, 0, time.UTC)
+
+	t.Run("empty", func(t *testing.T) {
+		finit, fend, err := conn.CurrencyValue.GetFinitAndFend()
+
+		assert.Nil(t, err)
+		assert.True(t, finit.IsZero())
+		assert.True(t, fend.IsZero())
+
+		vals, err := conn.CurrencyValue.ListCurrenciesByDateRange("all", nil, nil)
+
+		assert.Nil(t, err)
+		assert.Empty(t, vals)
+	})
+
+	t.Run("insert without request", func(t *testing.T) {
+		_, _, err := conn.CurrencyValue.BulkInsert([]repository.CurrencyValue{{Name: "MXN", RequestID: 1, Value: decimal.NewFromFloat(20)}})
+
+		assert.EqualError(t, err, "failed to insert multiple records at once: the request (1) does not exist")
+	})
+
+	for i, lastUpdated := range []time.Time{first, second} {
+		id, err := conn.RequestStatus.Insert(repository.RequestStatus{Status: "success", RequestedAt: lastUpdated})
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, i+1, id)
+
+		inserted, skipped, err := conn.CurrencyValue.BulkInsert([]repository.CurrencyValue{
+			{Name: "USD", RequestID: id, Value: decimal.NewFromFloat(1), LastUdatedAt: lastUpdated},
+			{Name: "MXN", RequestID: id, Value: decimal.NewFromInt(20 + int64(i)), LastUdatedAt: lastUpdated},
+			{Name: "EUR", RequestID: id, Value: decimal.NewFromFloat(0.5), LastUdatedAt: lastUpdated},
+		})
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, 3, inserted)
+		assert.EqualValues(t, 0, skipped)
+	}
+
+	t.Run("first and last dates", func(t *testing.T) {
+		finit, fend, err := conn.CurrencyValue.GetFinitAndFend()
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, first, finit)
+		assert.EqualValues(t, second, fend)
+	})
+
+	t.Run("list by date range", func(t *testing.T) {
+		all, err := conn.CurrencyValue.ListCurrenciesByDateRange("all", nil, nil)
+
+		assert.Nil(t, err)
+		assert.Len(t, all, 6)
+
+		vals, err := conn.CurrencyValue.ListCurrenciesByDateRange("MXN", &second, nil)
+
+		assert.Nil(t, err)
+		assert.Len(t, vals, 1)
+		assert.EqualValues(t, "21", vals[0].Value.String())
+
+		vals, err = conn.CurrencyValue.ListCurrenciesByDateRange("' OR '1'='1", nil, nil)
+
+		assert.Nil(t, err)
+		assert.Empty(t, vals)
+	})
+
+	t.Run("stored days", func(t *testing.T) {
+		days, err := conn.CurrencyValue.ListStoredDays(first.Add(-48*time.Hour), second)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, []time.Time{
+			time.Date(2022, 10, 17, 0, 0, 0, 0, time.UTC),
+			time.Date(2022, 10, 18, 0, 0, 0, 0, time.UTC),
+		}, days)
+
+		days, err = conn.CurrencyValue.ListStoredDays(second.Add(time.Second), second.Add(time.Hour))
+
+		assert.Nil(t, err)
+		assert.Empty(t, days)
+	})
+
+	t.Run("latest", func(t *testing.T) {
+		vals, err := conn.CurrencyValue.ListLatestCurrencies("all")
+
+		assert.Nil(t, err)
+		assert.Len(t, vals, 3)
+		assert.EqualValues(t, []string{"EUR", "MXN", "USD"}, []string{vals[0].Name, vals[1].Name, vals[2].Name})
+		assert.EqualValues(t, "21", vals[1].Value.String())
+		assert.EqualValues(t, 2, vals[1].RequestID)
+
+		vals, err = conn.CurrencyValue.ListLatestCurrencies("MXN")
+
+		assert.Nil(t, err)
+		assert.Len(t, vals, 1)
+		assert.EqualValues(t, second, vals[0].LastUdatedAt)
+	})
+
+	t.Run("cross rate", func(t *testing.T) {
+		cr, err := conn.CurrencyValue.GetCrossRate("EUR", "MXN", nil)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "42", cr.Rate.String())
+		assert.EqualValues(t, 2, cr.RequestID)
+		assert.EqualValues(t, second, cr.AsOf)
+
+		cr, err = conn.CurrencyValue.GetCrossRate("EUR", "MXN", &first)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, "40", cr.Rate.String())
+		assert.EqualValues(t, 1, cr.RequestID)
+
+		before := first.Add(-time.Hour)
+
+		_, err = conn.CurrencyValue.GetCrossRate("EUR", "MXN", &before)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+
+		_, err = conn.CurrencyValue.GetCrossRate("EUR", "JPY", nil)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("latest request by status", func(t *testing.T) {
+		rs, err := conn.RequestStatus.GetLatestByStatus("success")
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, second, rs.RequestedAt)
+
+		_, err = conn.RequestStatus.GetLatestByStatus("failure")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("list and get requests", func(t *testing.T) {
+		reqs, err := conn.RequestStatus.List(repository.RequestStatusFilter{})
+
+		assert.Nil(t, err)
+		assert.Len(t, reqs, 2)
+		assert.EqualValues(t, 2, reqs[0].ID)
+
+		reqs, err = conn.RequestStatus.List(repository.RequestStatusFilter{Fend: &first})
+
+		assert.Nil(t, err)
+		assert.Len(t, reqs, 1)
+		assert.EqualValues(t, 1, reqs[0].ID)
+
+		reqs, err = conn.RequestStatus.List(repository.RequestStatusFilter{Limit: 1, Offset: 1})
+
+		assert.Nil(t, err)
+		assert.Len(t, reqs, 1)
+		assert.EqualValues(t, 1, reqs[0].ID)
+
+		reqs, err = conn.RequestStatus.List(repository.RequestStatusFilter{Status: "failure"})
+
+		assert.Nil(t, err)
+		assert.Empty(t, reqs)
+
+		rs, err := conn.RequestStatus.Get(2)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, second, rs.RequestedAt)
+
+		_, err = conn.RequestStatus.Get(3)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+
+		vals, err := conn.CurrencyValue.ListCurrenciesByRequestID(1)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, []string{"EUR", "MXN", "USD"}, []string{vals[0].Name, vals[1].Name, vals[2].Name})
+		assert.EqualValues(t, "20", vals[1].Value.String())
+	})
+}
+
+func TestMemoryBulkInsertSkipsDuplicates(t *testing.T) {
+	conn := repository.NewMemoryConnection()
+
+	lastUpdated := time.Date(2022, 10, 18, 23, 59, 59, 0, time.UTC)
+
+	insert := func(provider string, cvs ...repository.CurrencyValue) (int, int) {
+		id, err := conn.RequestStatus.Insert(repository.RequestStatus{Status: "success", Provider: provider})
+		assert.Nil(t, err)
+
+		for i := range cvs {
+			cvs[i].RequestID = id
+			cvs[i].Provider = provider
+			cvs[i].LastUdatedAt = lastUpdated
+		}
+
+		inserted, skipped, err := conn.CurrencyValue.BulkInsert(cvs)
+		assert.Nil(t, err)
+
+		return inserted, skipped
+	}
+
+	usd := repository.CurrencyValue{Name: "USD", Value: decimal.NewFromInt(1)}
+	mxn := repository.CurrencyValue{Name: "MXN", Value: decimal.NewFromFloat(20.0512)}
+
+	inserted, skipped := insert("currencyapi", usd, mxn)
+	assert.EqualValues(t, 2, inserted)
+	assert.EqualValues(t, 0, skipped)
+
+	// a retried request stores only the new values
+	inserted, skipped = insert("currencyapi", usd, mxn, repository.CurrencyValue{Name: "EUR", Value: decimal.NewFromFloat(1.0167)})
+	assert.EqualValues(t, 1, inserted)
+	assert.EqualValues(t, 2, skipped)
+
+	// the same rate of another provider is not a duplicate
+	inserted, skipped = insert("ecb", mxn)
+	assert.EqualValues(t, 1, inserted)
+	assert.EqualValues(t, 0, skipped)
+
+	vals, err := conn.CurrencyValue.ListCurrenciesByDateRange("MXN", nil, nil)
+
+	assert.Nil(t, err)
+	assert.Len(t, vals, 2)
+}
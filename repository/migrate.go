@@ -0,0 +1,368 @@
+package repository
+
+import (
+	"database/sql"
+	"embed"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// migrationsFS contains all the numbered migrations, each version must have an up and a down file
+// named as <version>_<name>.<up|down>.sql, e.g.: 0001_create_tables.up.sql.
+//
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migrationFileRegexp matches the name of a migration file.
+var migrationFileRegexp = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// migrationsLockKey is the key of the advisory lock taken while a migration runs, so two
+// instances can't migrate the database at the same time.
+const migrationsLockKey = 4217001
+
+// Migration represents a version of the database schema.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus represents the state of a migration in the database.
+type MigrationStatus struct {
+	Migration
+
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrations returns all the embedded migrations sorted by version.
+func Migrations() ([]Migration, error) {
+	entries, err := migrationsFS.ReadDir("migrations")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the migrations")
+	}
+
+	byVersion := map[int]*Migration{}
+
+	for i := range entries {
+		parts := migrationFileRegexp.FindStringSubmatch(entries[i].Name())
+		if parts == nil {
+			return nil, errors.Errorf("the migration file (%s) has an invalid name", entries[i].Name())
+		}
+
+		version, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "the migration file (%s) has an invalid version", entries[i].Name())
+		}
+
+		blob, err := migrationsFS.ReadFile(path.Join("migrations", entries[i].Name()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read the migration file (%s)", entries[i].Name())
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[2]}
+			byVersion[version] = m
+		}
+
+		if m.Name != parts[2] {
+			return nil, errors.Errorf("the migration version (%d) has two names (%s) and (%s)", version, m.Name, parts[2])
+		}
+
+		if parts[3] == "up" {
+			m.Up = string(blob)
+		} else {
+			m.Down = string(blob)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, errors.Errorf("the migration version (%d) must have an up and a down file", m.Version)
+		}
+
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// ExpectedSchemaVersion returns the version of the latest embedded migration, which is the version
+// of the database schema that the binary expects.
+func ExpectedSchemaVersion() (int, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return 0, err
+	}
+
+	if len(migrations) == 0 {
+		return 0, nil
+	}
+
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// createSchemaMigrations creates the table that registers the applied migrations.
+func (conn *SQLConnection) createSchemaMigrations() error {
+	_, err := conn.sqlService.db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name VARCHAR NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+		);
+	`)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the schema_migrations table")
+	}
+
+	return nil
+}
+
+// appliedMigrations returns the applied versions with the time they were applied.
+func (conn *SQLConnection) appliedMigrations() (map[int]time.Time, error) {
+	rows, err := conn.sqlService.db.Query(`SELECT version, applied_at FROM schema_migrations;`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the applied migrations")
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt time.Time
+		)
+
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, errors.Wrap(err, "failed to scan the applied migrations")
+		}
+
+		applied[version] = appliedAt
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to iterate the applied migrations")
+	}
+
+	return applied, nil
+}
+
+// runMigration runs the statement of a migration and registers it in the same transaction, the
+// register callback must insert or delete the version from schema_migrations. It returns false
+// when other instance has already registered the migration.
+func (conn *SQLConnection) runMigration(statement string, register func(tx *sql.Tx) (sql.Result, error)) (bool, error) {
+	tx, err := conn.sqlService.db.Begin()
+	if err != nil {
+		return false, errors.Wrap(err, "could not start a new transaction")
+	}
+
+	rollback := func(err error, msg string) (bool, error) {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return false, errors.Wrap(rbErr, "failed to make a rollback")
+		}
+
+		if err == nil {
+			return false, nil
+		}
+
+		return false, errors.Wrap(err, msg)
+	}
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1);`, migrationsLockKey); err != nil {
+		return rollback(err, "failed to lock the migrations")
+	}
+
+	res, err := register(tx)
+	if err != nil {
+		return rollback(err, "failed to register the migration")
+	}
+
+	// other instance has already registered this migration while we were waiting for the lock
+	n, err := res.RowsAffected()
+	if err != nil || n == 0 {
+		return rollback(err, "failed to register the migration")
+	}
+
+	if _, err := tx.Exec(statement); err != nil {
+		return rollback(err, "failed to run the migration")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return true, nil
+}
+
+// MigrateUp applies all the pending migrations in order and returns the applied ones.
+func (conn *SQLConnection) MigrateUp() ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.createSchemaMigrations(); err != nil {
+		return nil, err
+	}
+
+	applied, err := conn.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	done := make([]Migration, 0)
+
+	for i := range migrations {
+		m := migrations[i]
+
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		ran, err := conn.runMigration(m.Up, func(tx *sql.Tx) (sql.Result, error) {
+			return tx.Exec(`
+				INSERT INTO schema_migrations (version, name)
+				VALUES ($1, $2)
+				ON CONFLICT (version) DO NOTHING;
+			`, m.Version, m.Name)
+		})
+		if err != nil {
+			return done, errors.Wrapf(err, "failed to apply the migration (%04d_%s)", m.Version, m.Name)
+		}
+
+		if ran {
+			done = append(done, m)
+		}
+	}
+
+	return done, nil
+}
+
+// MigrateDown rolls back the latest applied migration and returns it, if there is no migration
+// applied it returns ErrNotFound.
+func (conn *SQLConnection) MigrateDown() (Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return Migration{}, err
+	}
+
+	version, err := conn.SchemaVersion()
+	if err != nil {
+		return Migration{}, err
+	}
+
+	if version == 0 {
+		return Migration{}, ErrNotFound
+	}
+
+	for i := range migrations {
+		m := migrations[i]
+
+		if m.Version != version {
+			continue
+		}
+
+		ran, err := conn.runMigration(m.Down, func(tx *sql.Tx) (sql.Result, error) {
+			return tx.Exec(`DELETE FROM schema_migrations WHERE version = $1;`, m.Version)
+		})
+		if err != nil {
+			return Migration{}, errors.Wrapf(err, "failed to roll back the migration (%04d_%s)", m.Version, m.Name)
+		}
+
+		// other instance has already rolled back this migration
+		if !ran {
+			return Migration{}, ErrNotFound
+		}
+
+		return m, nil
+	}
+
+	return Migration{}, errors.Errorf("the migration version (%d) is not known by this binary", version)
+}
+
+// MigrationStatus returns all the embedded migrations telling which of them have been applied.
+func (conn *SQLConnection) MigrationStatus() ([]MigrationStatus, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.createSchemaMigrations(); err != nil {
+		return nil, err
+	}
+
+	applied, err := conn.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, 0, len(migrations))
+
+	for i := range migrations {
+		appliedAt, ok := applied[migrations[i].Version]
+
+		status = append(status, MigrationStatus{
+			Migration: migrations[i],
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+	}
+
+	return status, nil
+}
+
+// SchemaVersion returns the latest applied version, it is 0 when there are no migrations applied.
+func (conn *SQLConnection) SchemaVersion() (int, error) {
+	var exists bool
+
+	if err := conn.sqlService.db.QueryRow(`SELECT to_regclass('schema_migrations') IS NOT NULL;`).Scan(&exists); err != nil {
+		return 0, errors.Wrap(err, "failed to check the schema_migrations table")
+	}
+
+	if !exists {
+		return 0, nil
+	}
+
+	var version int
+
+	if err := conn.sqlService.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations;`).Scan(&version); err != nil {
+		return 0, errors.Wrap(err, "failed to get the schema version")
+	}
+
+	return version, nil
+}
+
+// CheckSchemaVersion returns an error when the database schema is behind the version expected
+// by the binary.
+func (conn *SQLConnection) CheckSchemaVersion() error {
+	expected, err := ExpectedSchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	version, err := conn.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	if version < expected {
+		return errors.Errorf("the database schema version (%d) is behind the expected version (%d), run the migrate up command",
+			version, expected)
+	}
+
+	return nil
+}
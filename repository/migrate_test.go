@@ -0,0 +1,47 @@
+package repository_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PacoDw/currency/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrations(t *testing.T) {
+	migrations, err := repository.Migrations()
+
+	assert.Nil(t, err)
+	assert.NotEmpty(t, migrations)
+
+	for i := range migrations {
+		assert.EqualValues(t, i+1, migrations[i].Version, "the migrations must be numbered without gaps")
+		assert.NotEmpty(t, strings.TrimSpace(migrations[i].Up))
+		assert.NotEmpty(t, strings.TrimSpace(migrations[i].Down))
+	}
+
+	expected, err := repository.ExpectedSchemaVersion()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, migrations[len(migrations)-1].Version, expected)
+}
+
+func TestMigrateUp(t *testing.T) {
+	TestEnvDBConnectionVariables(t)
+
+	conn := repository.NewSQLConnection(config)
+
+	assert.Condition(t, func() (success bool) { return assert.NotNil(t, conn) })
+
+	_, err := conn.MigrateUp()
+	assert.Nil(t, err)
+
+	status, err := conn.MigrationStatus()
+	assert.Nil(t, err)
+
+	for i := range status {
+		assert.True(t, status[i].Applied)
+	}
+
+	assert.Nil(t, conn.CheckSchemaVersion())
+}
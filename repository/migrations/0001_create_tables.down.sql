@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS currencies_values;
+
+DROP TABLE IF EXISTS requests_status;
@@ -0,0 +1,17 @@
+-- the tables are created only if they don't exist, so the databases created before the
+-- migrations can adopt this version without losing data
+CREATE TABLE IF NOT EXISTS requests_status (
+  id SERIAL PRIMARY KEY,
+  time_elapsed VARCHAR,
+  url VARCHAR,
+  status TEXT,
+  requested_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS currencies_values (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR,
+  request_id INTEGER REFERENCES requests_status(id),
+  value NUMERIC (10, 4),
+  last_updated_at TIMESTAMP
+);
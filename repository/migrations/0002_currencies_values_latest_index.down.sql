@@ -0,0 +1 @@
+DROP INDEX IF EXISTS currencies_values_name_last_updated_at_idx;
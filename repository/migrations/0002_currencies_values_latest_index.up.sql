@@ -0,0 +1,3 @@
+-- the latest value of each currency is found by walking this index backwards
+CREATE INDEX IF NOT EXISTS currencies_values_name_last_updated_at_idx
+  ON currencies_values (name, last_updated_at DESC, id DESC);
@@ -0,0 +1,2 @@
+-- Note: the values are rounded to 4 decimals and it fails if one of them doesn't fit in (10, 4)
+ALTER TABLE currencies_values ALTER COLUMN value TYPE NUMERIC (10, 4);
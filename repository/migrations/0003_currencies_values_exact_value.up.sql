@@ -0,0 +1,3 @@
+-- the value is stored without precision nor scale, so the rates of small-unit currencies and
+-- crypto currencies are stored exactly as the Currency Provider sends them
+ALTER TABLE currencies_values ALTER COLUMN value TYPE NUMERIC;
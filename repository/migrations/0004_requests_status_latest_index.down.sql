@@ -0,0 +1 @@
+DROP INDEX IF EXISTS requests_status_status_requested_at_idx;
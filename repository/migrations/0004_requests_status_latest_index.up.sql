@@ -0,0 +1,3 @@
+-- the health checks look for the latest request of each status
+CREATE INDEX IF NOT EXISTS requests_status_status_requested_at_idx
+  ON requests_status (status, requested_at DESC, id DESC);
@@ -0,0 +1,5 @@
+ALTER TABLE requests_status
+  DROP COLUMN IF EXISTS provider,
+  DROP COLUMN IF EXISTS status_code,
+  DROP COLUMN IF EXISTS response_bytes,
+  DROP COLUMN IF EXISTS error_message;
@@ -0,0 +1,7 @@
+-- the details of the response help to tell why a request to the Currency Provider failed, the
+-- rows inserted before this version keep the defaults
+ALTER TABLE requests_status
+  ADD COLUMN IF NOT EXISTS provider VARCHAR NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS status_code INTEGER NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS response_bytes BIGINT NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS error_message TEXT NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS currencies_values_request_id_idx;
@@ -0,0 +1,3 @@
+-- the currencies captured by a request are looked up by the request id
+CREATE INDEX IF NOT EXISTS currencies_values_request_id_idx
+  ON currencies_values (request_id);
@@ -0,0 +1,3 @@
+ALTER TABLE requests_status
+  DROP COLUMN IF EXISTS quota_limit,
+  DROP COLUMN IF EXISTS quota_remaining;
@@ -0,0 +1,4 @@
+-- the monthly quota reported by the Currency Provider, it is NULL when the provider does not report it
+ALTER TABLE requests_status
+  ADD COLUMN IF NOT EXISTS quota_limit BIGINT,
+  ADD COLUMN IF NOT EXISTS quota_remaining BIGINT;
@@ -0,0 +1,5 @@
+-- the duplicates removed by the up migration are not restored
+DROP INDEX IF EXISTS currencies_values_name_provider_last_updated_at_key;
+
+ALTER TABLE currencies_values
+  DROP COLUMN IF EXISTS provider;
@@ -0,0 +1,21 @@
+-- the provider of each value is taken from its request, so the same rate reported by two providers
+-- is not a duplicate
+ALTER TABLE currencies_values
+  ADD COLUMN IF NOT EXISTS provider VARCHAR NOT NULL DEFAULT '';
+
+UPDATE currencies_values AS cv
+SET provider = rs.provider
+FROM requests_status AS rs
+WHERE rs.id = cv.request_id
+AND cv.provider = '';
+
+-- the duplicates stored by a retried request or by several replicas are removed keeping the first one
+DELETE FROM currencies_values AS cv
+USING currencies_values AS dup
+WHERE cv.name = dup.name
+AND cv.provider = dup.provider
+AND cv.last_updated_at = dup.last_updated_at
+AND cv.id > dup.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS currencies_values_name_provider_last_updated_at_key
+  ON currencies_values (name, provider, last_updated_at);
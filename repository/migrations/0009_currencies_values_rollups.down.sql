@@ -0,0 +1,4 @@
+-- the values rolled up are not restored into currencies_values
+DROP TABLE IF EXISTS currencies_values_daily;
+
+DROP TABLE IF EXISTS currencies_values_hourly;
@@ -0,0 +1,30 @@
+-- the raw values older than the retention are rolled up into hourly aggregates and the hourly
+-- aggregates into daily ones, the sum is kept so the average of a coarser bucket is exact and the
+-- times of the first and the last value tell the open and the close of the merged buckets
+CREATE TABLE IF NOT EXISTS currencies_values_hourly (
+  name VARCHAR NOT NULL,
+  bucket TIMESTAMP NOT NULL,
+  opened_at TIMESTAMP NOT NULL,
+  open NUMERIC NOT NULL,
+  high NUMERIC NOT NULL,
+  low NUMERIC NOT NULL,
+  closed_at TIMESTAMP NOT NULL,
+  close NUMERIC NOT NULL,
+  sum NUMERIC NOT NULL,
+  count BIGINT NOT NULL,
+  PRIMARY KEY (name, bucket)
+);
+
+CREATE TABLE IF NOT EXISTS currencies_values_daily (
+  name VARCHAR NOT NULL,
+  bucket TIMESTAMP NOT NULL,
+  opened_at TIMESTAMP NOT NULL,
+  open NUMERIC NOT NULL,
+  high NUMERIC NOT NULL,
+  low NUMERIC NOT NULL,
+  closed_at TIMESTAMP NOT NULL,
+  close NUMERIC NOT NULL,
+  sum NUMERIC NOT NULL,
+  count BIGINT NOT NULL,
+  PRIMARY KEY (name, bucket)
+);
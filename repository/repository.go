@@ -1,6 +1,38 @@
 package repository
 
-import "database/sql"
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNotFound is returned when the requested record does not exist in the database.
+var ErrNotFound = errors.New("record not found")
+
+// ErrInvalidArgument is matched by errors.Is when a parameter of the query is not valid, e.g.: a bucket
+// that is not supported, the error message tells which parameter.
+var ErrInvalidArgument = errors.New("invalid argument")
+
+// argumentError represents a parameter that is not valid.
+type argumentError struct {
+	msg string
+}
+
+// Error returns the message of the parameter that is not valid.
+func (e *argumentError) Error() string {
+	return e.msg
+}
+
+// Is makes the error match ErrInvalidArgument.
+func (e *argumentError) Is(target error) bool {
+	return target == ErrInvalidArgument
+}
+
+// invalidArgumentf formats the message of a parameter that is not valid.
+func invalidArgumentf(format string, args ...interface{}) error {
+	return &argumentError{msg: fmt.Sprintf(format, args...)}
+}
 
 // nolint // sqlService represents a type for each service created, so all the servies like
 // device in device.go must implement this type.
@@ -13,6 +45,7 @@ type sqlService struct {
 // Note: If you has been created a new service it must be listed in this struct.
 type SQLConnection struct {
 	sqlService    *sqlService
+	leaderLock    *leaderLock
 	RequestStatus RequestStatusRepository
 	CurrencyValue CurrencyValueRepository
 }
@@ -34,6 +67,7 @@ func New(db *sql.DB, cfg *Config) *SQLConnection {
 
 	return &SQLConnection{
 		sqlService:    sqls,
+		leaderLock:    &leaderLock{},
 		RequestStatus: (*RequestStatusSQLService)(sqls),
 		CurrencyValue: (*CurrencyValueSQLService)(sqls),
 	}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"database/sql"
 	"time"
 
 	"github.com/pkg/errors"
@@ -9,6 +10,9 @@ import (
 // RequestStatusRepository defines the interface that device must satisfy.
 type RequestStatusRepository interface {
 	Insert(rs RequestStatus) (int64, error)
+	GetLatestByStatus(status string) (RequestStatus, error)
+	List(filter RequestStatusFilter) ([]RequestStatus, error)
+	Get(id int64) (RequestStatus, error)
 }
 
 // RequestStatusSQLService represents a sqlService type.
@@ -19,11 +23,87 @@ type RequestStatusSQLService sqlService
 // a sqlService type.
 var _ RequestStatusRepository = &RequestStatusSQLService{}
 
+// RequestStatus represents a request made to the Currency Provider.
 type RequestStatus struct {
-	TimeElapsed string
-	URL         string
-	Status      string
-	RequestedAt time.Time
+	ID          int64     `json:"id"`
+	TimeElapsed string    `json:"time_elapsed"`
+	URL         string    `json:"url"`
+	Status      string    `json:"status"`
+	RequestedAt time.Time `json:"requested_at"`
+
+	// Provider represents the name of the Currency Provider which answered the request.
+	Provider string `json:"provider"`
+
+	// StatusCode represents the http status code of the response, it is 0 when there was no response.
+	StatusCode int `json:"status_code"`
+
+	// ResponseBytes represents the size of the body of the response.
+	ResponseBytes int64 `json:"response_bytes"`
+
+	// ErrorMessage represents why the request failed, it is empty when it succeeded.
+	ErrorMessage string `json:"error_message"`
+
+	// QuotaLimit and QuotaRemaining represent the monthly quota of the Currency Provider account
+	// after the request, they are nil when the provider does not report them.
+	QuotaLimit     *int64 `json:"quota_limit"`
+	QuotaRemaining *int64 `json:"quota_remaining"`
+}
+
+// RequestStatusFilter represents the filters to list the requests, the attributes that are not set
+// are not applied.
+type RequestStatusFilter struct {
+	// Status represents the status of the requests, e.g.: success or failure.
+	Status string
+
+	// Finit and Fend represent the range of the time when the requests were made.
+	Finit *time.Time
+	Fend  *time.Time
+
+	// Limit represents the maximum number of requests returned, if it is 0 all of them are returned.
+	Limit int
+
+	// Offset represents the number of requests skipped.
+	Offset int
+}
+
+// requestStatusColumns are the columns scanned by scanRequestStatus.
+const requestStatusColumns = `
+	id,
+	time_elapsed,
+	url,
+	status,
+	requested_at,
+	provider,
+	status_code,
+	response_bytes,
+	error_message,
+	quota_limit,
+	quota_remaining`
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanRequestStatus scans a row with the requestStatusColumns.
+func scanRequestStatus(row rowScanner) (RequestStatus, error) {
+	var rs RequestStatus
+
+	err := row.Scan(
+		&rs.ID,
+		&rs.TimeElapsed,
+		&rs.URL,
+		&rs.Status,
+		&rs.RequestedAt,
+		&rs.Provider,
+		&rs.StatusCode,
+		&rs.ResponseBytes,
+		&rs.ErrorMessage,
+		&rs.QuotaLimit,
+		&rs.QuotaRemaining,
+	)
+
+	return rs, err
 }
 
 // Insert creates registers into the database about all request made.
@@ -39,11 +119,20 @@ func (service *RequestStatusSQLService) Insert(rs RequestStatus) (int64, error)
 				time_elapsed,
 				url,
 				status,
-				requested_at
+				requested_at,
+				provider,
+				status_code,
+				response_bytes,
+				error_message,
+				quota_limit,
+				quota_remaining
 			)
 		VALUES 
-			($1,$2,$3,$4)
-		RETURNING id;`, rs.TimeElapsed, rs.URL, rs.Status, rs.RequestedAt)
+			($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+		RETURNING id;`,
+		rs.TimeElapsed, rs.URL, rs.Status, rs.RequestedAt, rs.Provider, rs.StatusCode, rs.ResponseBytes, rs.ErrorMessage,
+		rs.QuotaLimit, rs.QuotaRemaining,
+	)
 	if res.Err() != nil {
 		if err := tx.Rollback(); err != nil {
 			return 0, errors.Wrap(err, "failed to make a rollback")
@@ -63,3 +152,98 @@ func (service *RequestStatusSQLService) Insert(rs RequestStatus) (int64, error)
 
 	return id, nil
 }
+
+// GetLatestByStatus returns the most recent request with the status, e.g.: the last success or the last
+// failure, if there is no request with that status it returns ErrNotFound.
+func (service *RequestStatusSQLService) GetLatestByStatus(status string) (RequestStatus, error) {
+	rs, err := scanRequestStatus(service.db.QueryRow(`
+		SELECT`+requestStatusColumns+`
+		FROM requests_status
+		WHERE status = $1
+		ORDER BY requested_at DESC, id DESC
+		LIMIT 1;`, status))
+	if err == sql.ErrNoRows {
+		return RequestStatus{}, ErrNotFound
+	}
+
+	if err != nil {
+		return RequestStatus{}, errors.Wrap(err, "failed to get the latest request status")
+	}
+
+	return rs, nil
+}
+
+// List retrieves the requests that match the filter sorted from the newest to the oldest.
+// Note: these filters are passed as bind parameters.
+func (service *RequestStatusSQLService) List(filter RequestStatusFilter) ([]RequestStatus, error) {
+	// a NULL means that the filter is not applied
+	var status, finit, fend, limit interface{}
+
+	if filter.Status != "" {
+		status = filter.Status
+	}
+
+	if filter.Finit != nil && !filter.Finit.IsZero() {
+		finit = *filter.Finit
+	}
+
+	if filter.Fend != nil && !filter.Fend.IsZero() {
+		fend = *filter.Fend
+	}
+
+	if filter.Limit > 0 {
+		limit = filter.Limit
+	}
+
+	rows, err := service.db.Query(`
+		SELECT`+requestStatusColumns+`
+		FROM requests_status
+		WHERE
+			($1::VARCHAR IS NULL OR status = $1::VARCHAR)
+		AND
+			($2::TIMESTAMP IS NULL OR requested_at >= $2::TIMESTAMP)
+		AND
+			($3::TIMESTAMP IS NULL OR requested_at <= $3::TIMESTAMP)
+		ORDER BY requested_at DESC, id DESC
+		LIMIT $4::INTEGER
+		OFFSET $5::INTEGER;
+	`, status, finit, fend, limit, filter.Offset)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the requests status")
+	}
+	defer rows.Close()
+
+	reqs := make([]RequestStatus, 0)
+
+	for rows.Next() {
+		rs, err := scanRequestStatus(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scanning multiple records")
+		}
+
+		reqs = append(reqs, rs)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to iterate the records")
+	}
+
+	return reqs, nil
+}
+
+// Get retrieves the request with the id, if it does not exist it returns ErrNotFound.
+func (service *RequestStatusSQLService) Get(id int64) (RequestStatus, error) {
+	rs, err := scanRequestStatus(service.db.QueryRow(`
+		SELECT`+requestStatusColumns+`
+		FROM requests_status
+		WHERE id = $1;`, id))
+	if err == sql.ErrNoRows {
+		return RequestStatus{}, ErrNotFound
+	}
+
+	if err != nil {
+		return RequestStatus{}, errors.Wrap(err, "failed to get the request status")
+	}
+
+	return rs, nil
+}
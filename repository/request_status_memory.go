@@ -0,0 +1,111 @@
+package repository
+
+import "sort"
+
+// RequestStatusMemoryService represents a memoryService type.
+type RequestStatusMemoryService memoryService
+
+// RequestStatusMemoryService validate if it satisfy the own interface.
+var _ RequestStatusRepository = &RequestStatusMemoryService{}
+
+// Insert registers the request made and returns its id, the ids start at 1 like a SERIAL column.
+func (service *RequestStatusMemoryService) Insert(rs RequestStatus) (int64, error) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	id := int64(len(service.requestsStatus)) + 1
+	rs.ID = id
+
+	service.requestsStatus = append(service.requestsStatus, memoryRequestStatus{id: id, RequestStatus: rs})
+
+	return id, nil
+}
+
+// GetLatestByStatus returns the most recent request with the status, if there is no request with that
+// status it returns ErrNotFound.
+func (service *RequestStatusMemoryService) GetLatestByStatus(status string) (RequestStatus, error) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+
+	var (
+		latest RequestStatus
+		found  bool
+	)
+
+	// the later inserted wins a tie like the id in the sql query
+	for i := range service.requestsStatus {
+		rs := service.requestsStatus[i].RequestStatus
+
+		if rs.Status != status || (found && rs.RequestedAt.Before(latest.RequestedAt)) {
+			continue
+		}
+
+		latest, found = rs, true
+	}
+
+	if !found {
+		return RequestStatus{}, ErrNotFound
+	}
+
+	return latest, nil
+}
+
+// List retrieves the requests that match the filter sorted from the newest to the oldest.
+func (service *RequestStatusMemoryService) List(filter RequestStatusFilter) ([]RequestStatus, error) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+
+	reqs := make([]RequestStatus, 0)
+
+	for i := range service.requestsStatus {
+		rs := service.requestsStatus[i].RequestStatus
+
+		if filter.Status != "" && rs.Status != filter.Status {
+			continue
+		}
+
+		if filter.Finit != nil && !filter.Finit.IsZero() && rs.RequestedAt.Before(*filter.Finit) {
+			continue
+		}
+
+		if filter.Fend != nil && !filter.Fend.IsZero() && rs.RequestedAt.After(*filter.Fend) {
+			continue
+		}
+
+		reqs = append(reqs, rs)
+	}
+
+	sort.SliceStable(reqs, func(i, j int) bool {
+		if !reqs[i].RequestedAt.Equal(reqs[j].RequestedAt) {
+			return reqs[i].RequestedAt.After(reqs[j].RequestedAt)
+		}
+
+		return reqs[i].ID > reqs[j].ID
+	})
+
+	if filter.Offset >= len(reqs) {
+		return []RequestStatus{}, nil
+	}
+
+	if filter.Offset > 0 {
+		reqs = reqs[filter.Offset:]
+	}
+
+	if filter.Limit > 0 && filter.Limit < len(reqs) {
+		reqs = reqs[:filter.Limit]
+	}
+
+	return reqs, nil
+}
+
+// Get retrieves the request with the id, if it does not exist it returns ErrNotFound.
+func (service *RequestStatusMemoryService) Get(id int64) (RequestStatus, error) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+
+	if !(*memoryService)(service).requestExists(id) {
+		return RequestStatus{}, ErrNotFound
+	}
+
+	return service.requestsStatus[id-1].RequestStatus, nil
+}
@@ -18,22 +18,22 @@ func TestInsertRequestStatus(t *testing.T) {
 
 	reqs := []repository.RequestStatus{
 		{
-			time.Duration(1 * time.Second).String(),
-			fake.DomainName(),
-			fake.Gender(),
-			time.Now(),
+			TimeElapsed: time.Duration(1 * time.Second).String(),
+			URL:         fake.DomainName(),
+			Status:      fake.Gender(),
+			RequestedAt: time.Now(),
 		},
 		{
-			time.Duration(1 * time.Second).String(),
-			fake.DomainName(),
-			fake.Gender(),
-			time.Now(),
+			TimeElapsed: time.Duration(1 * time.Second).String(),
+			URL:         fake.DomainName(),
+			Status:      fake.Gender(),
+			RequestedAt: time.Now(),
 		},
 		{
-			time.Duration(1 * time.Second).String(),
-			fake.DomainName(),
-			fake.Gender(),
-			time.Now(),
+			TimeElapsed: time.Duration(1 * time.Second).String(),
+			URL:         fake.DomainName(),
+			Status:      fake.Gender(),
+			RequestedAt: time.Now(),
 		},
 	}
 
@@ -44,3 +44,76 @@ func TestInsertRequestStatus(t *testing.T) {
 		assert.EqualValues(t, i+1, reqID)
 	}
 }
+
+func TestGetLatestRequestStatusByStatus(t *testing.T) {
+	TestEnvDBConnectionVariables(t)
+
+	conn := repository.NewSQLConnection(config)
+
+	assert.Condition(t, func() (success bool) { return assert.NotNil(t, conn) })
+
+	requestedAt := time.Now().UTC().Truncate(time.Second).Add(time.Hour)
+	quotaLimit := int64(300)
+
+	_, err := conn.RequestStatus.Insert(repository.RequestStatus{
+		Status:        "latest-test",
+		URL:           fake.DomainName(),
+		RequestedAt:   requestedAt,
+		Provider:      "currencyapi",
+		StatusCode:    429,
+		ResponseBytes: 64,
+		ErrorMessage:  "unexpected status code 429",
+		QuotaLimit:    &quotaLimit,
+	})
+	assert.Condition(t, func() (success bool) { return assert.Nil(t, err) })
+
+	rs, err := conn.RequestStatus.GetLatestByStatus("latest-test")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, requestedAt, rs.RequestedAt.UTC())
+	assert.EqualValues(t, "currencyapi", rs.Provider)
+	assert.EqualValues(t, 429, rs.StatusCode)
+	assert.EqualValues(t, 64, rs.ResponseBytes)
+	assert.EqualValues(t, "unexpected status code 429", rs.ErrorMessage)
+	assert.EqualValues(t, &quotaLimit, rs.QuotaLimit)
+	assert.Nil(t, rs.QuotaRemaining)
+
+	_, err = conn.RequestStatus.GetLatestByStatus("unknown-status")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestListAndGetRequestStatus(t *testing.T) {
+	TestEnvDBConnectionVariables(t)
+
+	conn := repository.NewSQLConnection(config)
+
+	assert.Condition(t, func() (success bool) { return assert.NotNil(t, conn) })
+
+	requestedAt := time.Now().UTC().Truncate(time.Second).Add(2 * time.Hour)
+
+	id, err := conn.RequestStatus.Insert(repository.RequestStatus{Status: "list-test", URL: fake.DomainName(), RequestedAt: requestedAt})
+	assert.Condition(t, func() (success bool) { return assert.Nil(t, err) })
+
+	_, err = conn.RequestStatus.Insert(repository.RequestStatus{Status: "list-test", URL: fake.DomainName(), RequestedAt: requestedAt.Add(time.Minute)})
+	assert.Condition(t, func() (success bool) { return assert.Nil(t, err) })
+
+	reqs, err := conn.RequestStatus.List(repository.RequestStatusFilter{Status: "list-test", Limit: 1, Offset: 1})
+
+	assert.Nil(t, err)
+	if assert.Len(t, reqs, 1) {
+		assert.EqualValues(t, id, reqs[0].ID)
+	}
+
+	rs, err := conn.RequestStatus.Get(id)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "list-test", rs.Status)
+
+	_, err = conn.RequestStatus.Get(-1)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	vals, err := conn.CurrencyValue.ListCurrenciesByRequestID(id)
+
+	assert.Nil(t, err)
+	assert.Empty(t, vals)
+}
@@ -0,0 +1,94 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/PacoDw/currency/iso4217"
+	"github.com/PacoDw/currency/repository"
+	"github.com/shopspring/decimal"
+)
+
+// CurrencyQueryParameter represents a query parameter that holds a currency code.
+type CurrencyQueryParameter string
+
+const (
+	// From represents the currency code of the amount to convert, e.g.: USD.
+	From CurrencyQueryParameter = CurrencyQueryParameter("from")
+
+	// To represents the currency code which the amount is converted to, e.g.: MXN.
+	To CurrencyQueryParameter = CurrencyQueryParameter("to")
+)
+
+// AmountQueryParameter represents a query parameter that holds an amount of money.
+type AmountQueryParameter string
+
+// Amount represents the amount of money to convert, e.g.: 125.50.
+const Amount AmountQueryParameter = AmountQueryParameter("amount")
+
+// AsOf represents the moment of the snapshot used to convert, if it is not set the latest
+// snapshot is used.
+const AsOf DateTimeQueryParameter = DateTimeQueryParameter("as_of")
+
+// unitsDecimalPlaces is the number of decimal places used to round the converted amount when the
+// target currency has no minor units, like the precious metals.
+const unitsDecimalPlaces = 4
+
+// Conversion represents the result of converting an amount from one currency to another.
+type Conversion struct {
+	From   string          `json:"from"`
+	To     string          `json:"to"`
+	Amount decimal.Decimal `json:"amount"`
+	Result decimal.Decimal `json:"result"`
+
+	repository.CrossRate
+}
+
+// ConvertRoute converts an amount between two currencies, it accepts the query parameters 'from', 'to'
+// and 'amount' which are required, and 'as_of' which is optional to pick an older snapshot.
+// Note: the rates are triangulated through the base currency of the Currency Provider and both of them
+// are taken from the same snapshot.
+func ConvertRoute(repo repository.CurrencyValueRepository) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			from   = r.Context().Value(From).(string)
+			to     = r.Context().Value(To).(string)
+			amount = r.Context().Value(Amount).(decimal.Decimal)
+			asOf   = r.Context().Value(AsOf).(time.Time)
+		)
+
+		// get the rate between both currencies from the repository
+		cr, err := repo.GetCrossRate(from, to, &asOf)
+		if err != nil {
+			writeRepositoryError(w, err, fmt.Sprintf("there is no snapshot with the currencies (%s) and (%s)", from, to))
+
+			return
+		}
+
+		WriteJSON(w, http.StatusOK, Conversion{
+			From:      from,
+			To:        to,
+			Amount:    amount,
+			Result:    cr.Convert(amount).Round(conversionDecimalPlaces(to)),
+			CrossRate: cr,
+		})
+	}
+}
+
+// conversionDecimalPlaces returns the number of decimal places used to round an amount of the currency,
+// it is the ISO 4217 minor units of the currency, e.g.: 2 for MXN or 0 for JPY. The amount is rounded
+// half away from zero so all the clients get the same result.
+func conversionDecimalPlaces(currency string) int32 {
+	c, ok := iso4217.Lookup(currency)
+	if !ok {
+		return unitsDecimalPlaces
+	}
+
+	digits, ok := c.Digits()
+	if !ok {
+		return unitsDecimalPlaces
+	}
+
+	return int32(digits)
+}
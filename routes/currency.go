@@ -2,9 +2,11 @@ package routes
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/PacoDw/currency/iso4217"
 	"github.com/PacoDw/currency/repository"
 )
 
@@ -20,26 +22,70 @@ const (
 	Fend DateTimeQueryParameter = DateTimeQueryParameter("fend")
 )
 
+// BucketQueryParameter represents the query parameter to aggregate the values in time buckets.
+type BucketQueryParameter string
+
+// Bucket represents the size of the time buckets, e.g.: 1h, 1d or 1w.
+const Bucket BucketQueryParameter = BucketQueryParameter("bucket")
+
 // RouteParameter represents the required route parameter for endopoints.
 type RouteParameter string
 
 // Currency represens the number of the currency provider. this paramter is required
 const Currency RouteParameter = RouteParameter("currency")
 
+// StatusQueryParameter represents the query parameter to filter the ISO 4217 currencies by status.
+type StatusQueryParameter string
+
+// Status represents the status of the currencies to list, e.g.: active or withdrawn.
+const Status StatusQueryParameter = StatusQueryParameter("status")
+
+// ListCurrenciesRoute lists the ISO 4217 currencies known by the service with their numeric code, name,
+// minor units and status, the optional query parameter 'status' filters them by active or withdrawn.
+func ListCurrenciesRoute() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := iso4217.Status(r.URL.Query().Get(string(Status)))
+
+		switch status {
+		case "":
+			WriteJSON(w, http.StatusOK, iso4217.List())
+		case iso4217.Active, iso4217.Withdrawn:
+			WriteJSON(w, http.StatusOK, iso4217.List(status))
+		default:
+			writeError(w, http.StatusBadRequest,
+				fmt.Sprintf("bad query parameter (%s) with value (%s). it must be active or withdrawn", Status, status))
+		}
+	}
+}
+
 // CurrencyRoute represents the main rout to handle request accepting a route parameter called 'currency'
 // which is required and query parameters with time type such as: finit and fend these parameter are not required.
-func CurrencyRoute(repo *repository.SQLConnection) func(w http.ResponseWriter, r *http.Request) {
+// If the query parameter bucket is set, the values are aggregated in time buckets with open, high, low, close,
+// avg and count instead of returning the raw values. The values rolled up by the retention are read from their
+// hourly or daily aggregates, so the resolution follows the age of the requested range.
+func CurrencyRoute(repo repository.CurrencyValueRepository) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var (
-			curr  = r.Context().Value(Currency).(string)
-			finit = r.Context().Value(Finit).(time.Time)
-			fend  = r.Context().Value(Fend).(time.Time)
+			curr      = r.Context().Value(Currency).(string)
+			finit     = r.Context().Value(Finit).(time.Time)
+			fend      = r.Context().Value(Fend).(time.Time)
+			bucket, _ = r.Context().Value(Bucket).(repository.Bucket)
+		)
+
+		var (
+			data interface{}
+			err  error
 		)
 
 		// get the data from the repository
-		data, err := repo.CheckConn().CurrencyValue.ListCurrenciesByDateRange(curr, &finit, &fend)
+		if bucket != "" {
+			data, err = repo.ListCurrencyBuckets(curr, bucket, &finit, &fend)
+		} else {
+			data, err = repo.ListCurrenciesByDateRange(curr, &finit, &fend)
+		}
+
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
+			writeRepositoryError(w, err, "")
 
 			return
 		}
@@ -62,3 +108,46 @@ func CurrencyRoute(repo *repository.SQLConnection) func(w http.ResponseWriter, r
 		}
 	}
 }
+
+// LatestCurrencyRoute represents the route to get the latest value stored of the currency passed in the
+// route parameter 'currency', if it is 'all' then the latest value of each currency is returned. Each
+// value has the request_id of the snapshot it came from.
+func LatestCurrencyRoute(repo repository.CurrencyValueRepository) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		curr := r.Context().Value(Currency).(string)
+
+		data, err := repo.ListLatestCurrencies(curr)
+		if err != nil {
+			writeRepositoryError(w, err, "")
+
+			return
+		}
+
+		WriteJSON(w, http.StatusOK, data)
+	}
+}
+
+// CurrencyGapsRoute returns the windows without requests of the currency passed in the route parameter
+// 'currency', or of each currency using 'all', comparing the requests made to the Currency Provider against
+// the runs of the schedule, a request that found the rates unchanged covers the window too. Each window has
+// the failed requests made inside it, so a missing value caused by the job can be told apart from a rate
+// that was not updated. The optional query parameters finit and fend limit the windows, a range with too
+// many runs of the schedule is a bad request.
+func CurrencyGapsRoute(repo repository.CurrencyValueRepository, sched repository.Schedule) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			curr  = r.Context().Value(Currency).(string)
+			finit = r.Context().Value(Finit).(time.Time)
+			fend  = r.Context().Value(Fend).(time.Time)
+		)
+
+		gaps, err := repo.ListCurrencyGaps(curr, sched, &finit, &fend)
+		if err != nil {
+			writeRepositoryError(w, err, "")
+
+			return
+		}
+
+		WriteJSON(w, http.StatusOK, gaps)
+	}
+}
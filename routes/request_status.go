@@ -0,0 +1,103 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/PacoDw/currency/repository"
+)
+
+// PaginationQueryParameter represents a query parameter to page a list.
+type PaginationQueryParameter string
+
+const (
+	// Limit represents the maximum number of records returned, e.g.: 50.
+	Limit PaginationQueryParameter = PaginationQueryParameter("limit")
+
+	// Offset represents the number of records skipped, e.g.: 100.
+	Offset PaginationQueryParameter = PaginationQueryParameter("offset")
+)
+
+// ID represents the id of a record. this paramter is required
+const ID RouteParameter = RouteParameter("id")
+
+const (
+	// defaultRequestsLimit is the number of requests returned when the limit is not set.
+	defaultRequestsLimit = 50
+
+	// maxRequestsLimit is the maximum number of requests returned at once.
+	maxRequestsLimit = 500
+)
+
+// RequestStatusDetail represents a request made to the Currency Provider with the currency values
+// captured by it.
+type RequestStatusDetail struct {
+	repository.RequestStatus
+
+	CurrenciesValues []repository.CurrencyValue `json:"currencies_values"`
+}
+
+// ListRequestsStatusRoute lists the requests made to the Currency Provider from the newest to the oldest,
+// it accepts the query parameters 'status', 'finit' and 'fend' to filter them and 'limit' and 'offset'
+// to page them, all of them are optional. The limit is 50 by default and 500 at most.
+func ListRequestsStatusRoute(repo repository.RequestStatusRepository) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			finit     = r.Context().Value(Finit).(time.Time)
+			fend      = r.Context().Value(Fend).(time.Time)
+			limit, _  = r.Context().Value(Limit).(int)
+			offset, _ = r.Context().Value(Offset).(int)
+		)
+
+		if limit == 0 {
+			limit = defaultRequestsLimit
+		}
+
+		if limit > maxRequestsLimit {
+			limit = maxRequestsLimit
+		}
+
+		reqs, err := repo.List(repository.RequestStatusFilter{
+			Status: r.URL.Query().Get(string(Status)),
+			Finit:  &finit,
+			Fend:   &fend,
+			Limit:  limit,
+			Offset: offset,
+		})
+		if err != nil {
+			writeRepositoryError(w, err, "")
+
+			return
+		}
+
+		WriteJSON(w, http.StatusOK, reqs)
+	}
+}
+
+// RequestStatusRoute returns the request made to the Currency Provider with the route parameter 'id'
+// and the currency values captured by it, which are empty when the request failed.
+func RequestStatusRoute(rs repository.RequestStatusRepository, cv repository.CurrencyValueRepository) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.Context().Value(ID).(int64)
+
+		req, err := rs.Get(id)
+		if err != nil {
+			writeRepositoryError(w, err, fmt.Sprintf("the request (%d) does not exist", id))
+
+			return
+		}
+
+		vals, err := cv.ListCurrenciesByRequestID(id)
+		if err != nil {
+			writeRepositoryError(w, err, "")
+
+			return
+		}
+
+		WriteJSON(w, http.StatusOK, RequestStatusDetail{
+			RequestStatus:    req,
+			CurrenciesValues: vals,
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/PacoDw/currency/repository"
+	"go.uber.org/zap"
+)
+
+// writeRepositoryError writes a not found error with the msg when the record does not exist and a bad
+// request error when a parameter is not valid, any other error is a failure of the repository, e.g.: the
+// database is not reachable, so it logs the error and writes an internal server error without its details.
+func writeRepositoryError(w http.ResponseWriter, err error, msg string) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		writeError(w, http.StatusNotFound, msg)
+	case errors.Is(err, repository.ErrInvalidArgument):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		zap.L().Error("the repository failed", zap.Error(err))
+
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// writeError writes the msg as a json error with the given status code.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	WriteJSON(w, status, map[string]string{"error": msg})
+}
+
+// WriteJSON marshals v and writes it as json with the given status code.
+func WriteJSON(w http.ResponseWriter, status int, v interface{}) {
+	blob, err := json.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+
+	w.WriteHeader(status)
+
+	if _, err := w.Write(blob); err != nil {
+		return
+	}
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PacoDw/currency/providers"
+	"go.uber.org/zap"
+)
+
+// DateLayout is the layout of the days of a backfill, e.g.: 2022-10-19.
+const DateLayout = "2006-01-02"
+
+// BackfillResult represents the number of days stored, skipped because they already had currency values
+// and failed by a backfill.
+type BackfillResult struct {
+	Stored  int
+	Skipped int
+	Failed  int
+}
+
+// Backfill fills the history between the days of from and to, both included and taken in UTC, requesting
+// the historical exchange rates to the Currency Provider day by day. The days with currency values are
+// skipped, so a backfill that was stopped is resumed running it again with the same dates. Each request
+// is limited by the timeout of the Currency Provider and a failed day is logged and left for the next
+// backfill. Today and the days after it are left to the provider job.
+func (s *Server) Backfill(ctx context.Context, from, to time.Time) (BackfillResult, error) {
+	var result BackfillResult
+
+	hc, ok := s.currencyClient.(providers.HistoricalCurrencier)
+	if !ok {
+		return result, providers.ErrHistoricalNotSupported
+	}
+
+	from, to = truncateDay(from), truncateDay(to)
+
+	if yesterday := truncateDay(time.Now()).AddDate(0, 0, -1); to.After(yesterday) {
+		to = yesterday
+	}
+
+	if from.After(to) {
+		return result, fmt.Errorf("there are no days to backfill between %s and %s", from.Format(DateLayout), to.Format(DateLayout))
+	}
+
+	days, err := s.currencyValue.ListStoredDays(from, to.AddDate(0, 0, 1).Add(-time.Nanosecond))
+	if err != nil {
+		return result, err
+	}
+
+	stored := make(map[time.Time]bool, len(days))
+	for i := range days {
+		stored[days[i]] = true
+	}
+
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if stored[day] {
+			result.Skipped++
+
+			continue
+		}
+
+		// the backfill was stopped, the days left are filled by the next one
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		if err := s.backfillDay(ctx, hc, day); err != nil {
+			s.logger.Warn("Failed to backfill the day", zap.String("day", day.Format(DateLayout)), zap.Error(err))
+
+			result.Failed++
+
+			continue
+		}
+
+		result.Stored++
+	}
+
+	return result, nil
+}
+
+// backfillDay requests the historical exchange rates of the day and stores them like the provider job,
+// the request is registered with the backfill status.
+func (s *Server) backfillDay(ctx context.Context, hc providers.HistoricalCurrencier, day time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, hc.GetTimeoutRequest())
+	defer cancel()
+
+	start := time.Now()
+	meta, rates, errReq := hc.GetHistoricalExchangeRates(ctx, day)
+
+	// the rates of a past day are not fresh, so the request is not a success of the provider job
+	if errReq == nil && meta.Status == "success" {
+		meta.Status = statusBackfill
+	}
+
+	return s.storeRequest(start, meta, rates, errReq)
+}
+
+// truncateDay returns the midnight in UTC of the day of t.
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
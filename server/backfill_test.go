@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PacoDw/currency/providers"
+	"github.com/PacoDw/currency/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackfill(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		requested []string
+		failing   = map[string]bool{"2022-01-04": true}
+	)
+
+	// the historical endpoint answers with the rates at the end of the requested day
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		date := r.URL.Query().Get("date")
+
+		mu.Lock()
+		requested = append(requested, date)
+		fail := failing[date]
+		mu.Unlock()
+
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		fmt.Fprintf(w, `{"meta": {"last_updated_at": "%sT23:59:59Z"}, "data": {"USD": {"code": "USD", "value": 1}, "MXN": {"code": "MXN", "value": 20}}}`, date)
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL + "/v3/latest")
+	assert.Nil(t, err)
+
+	repo := repository.NewMemoryConnection()
+
+	s := New(
+		Repository(repo.RequestStatus, repo.CurrencyValue),
+		CurrencyProvider(providers.NewFreeCurrencyAPI(&providers.CurrencyConfig{URL: u, APIKey: "API_KEY", Timeout: time.Second})),
+	)
+
+	// the second day is already stored
+	seedSnapshot(t, repo, time.Date(2022, 1, 2, 12, 0, 0, 0, time.UTC), map[string]float64{"USD": 1, "MXN": 19})
+
+	from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2022, 1, 5, 18, 0, 0, 0, time.UTC)
+
+	result, err := s.Backfill(context.Background(), from, to)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, BackfillResult{Stored: 3, Skipped: 1, Failed: 1}, result)
+	assert.EqualValues(t, []string{"2022-01-01", "2022-01-03", "2022-01-04", "2022-01-05"}, requested)
+
+	cr, err := repo.CurrencyValue.GetCrossRate("USD", "MXN", nil)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "20", cr.Rate.String())
+	assert.EqualValues(t, time.Date(2022, 1, 5, 23, 59, 59, 0, time.UTC), cr.AsOf)
+
+	// the backfill is not a success of the provider job, the last one is still the stored day
+	_, err = repo.RequestStatus.GetLatestByStatus(statusBackfill)
+	assert.Nil(t, err)
+
+	last, err := s.lastSuccess()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, last.ID)
+
+	t.Run("resumes the failed days", func(t *testing.T) {
+		mu.Lock()
+		requested = nil
+		failing = map[string]bool{}
+		mu.Unlock()
+
+		result, err := s.Backfill(context.Background(), from, to)
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, BackfillResult{Stored: 1, Skipped: 4}, result)
+		assert.EqualValues(t, []string{"2022-01-04"}, requested)
+	})
+
+	t.Run("stopped", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		result, err := s.Backfill(ctx, from, from.AddDate(0, 0, 10))
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.EqualValues(t, BackfillResult{Skipped: 5}, result)
+	})
+
+	t.Run("without days", func(t *testing.T) {
+		_, err := s.Backfill(context.Background(), to, from)
+
+		assert.EqualError(t, err, "there are no days to backfill between 2022-01-05 and 2022-01-01")
+
+		// today is left to the provider job
+		_, err = s.Backfill(context.Background(), time.Now(), time.Now())
+
+		assert.NotNil(t, err)
+	})
+}
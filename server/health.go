@@ -0,0 +1,225 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/PacoDw/currency/providers"
+	"github.com/PacoDw/currency/repository"
+	"github.com/PacoDw/currency/routes"
+)
+
+// freshnessFactor is the number of planned requests that can pass without a successful request to
+// the Currency Provider before the instance is reported as not ready.
+const freshnessFactor = 3
+
+// pingTimeout limits the time waiting for the database to answer the ping.
+const pingTimeout = 2 * time.Second
+
+const (
+	checkOK   = "ok"
+	checkFail = "fail"
+)
+
+// healthCheck represents the result of checking a dependency of the server.
+type healthCheck struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// readiness represents the response of the readiness route.
+type readiness struct {
+	Status string                 `json:"status"`
+	Checks map[string]healthCheck `json:"checks"`
+}
+
+// databaseStats represents the statistics of the connection pool.
+type databaseStats struct {
+	MaxOpenConnections int    `json:"max_open_connections"`
+	OpenConnections    int    `json:"open_connections"`
+	InUse              int    `json:"in_use"`
+	Idle               int    `json:"idle"`
+	WaitCount          int64  `json:"wait_count"`
+	WaitDuration       string `json:"wait_duration"`
+}
+
+// status represents the response of the status route with the details of the server.
+type status struct {
+	readiness
+
+	StartedAt       time.Time                 `json:"started_at"`
+	Uptime          string                    `json:"uptime"`
+	RequestInterval string                    `json:"request_interval"`
+	Schedule        string                    `json:"schedule"`
+	NextRunAt       *time.Time                `json:"next_run_at"`
+	Leader          bool                      `json:"leader"`
+	LastSuccessAt   *time.Time                `json:"last_success_at"`
+	LastError       *repository.RequestStatus `json:"last_error"`
+	Database        *databaseStats            `json:"database"`
+}
+
+// healthzRoute tells that the process is up, it does not check any dependency.
+func (s *Server) healthzRoute(w http.ResponseWriter, r *http.Request) {
+	routes.WriteJSON(w, http.StatusOK, healthCheck{Status: checkOK})
+}
+
+// readyzRoute tells if the instance can handle requests, the database must answer the ping and the
+// Currency Provider job must have stored a successful request recently.
+func (s *Server) readyzRoute(w http.ResponseWriter, r *http.Request) {
+	rd, _ := s.checkReadiness(r.Context())
+
+	routes.WriteJSON(w, rd.statusCode(), rd)
+}
+
+// statusRoute returns the readiness with the details about the provider job and the database.
+func (s *Server) statusRoute(w http.ResponseWriter, r *http.Request) {
+	rd, lastSuccessAt := s.checkReadiness(r.Context())
+
+	st := status{
+		readiness:       rd,
+		StartedAt:       s.startedAt,
+		Uptime:          time.Since(s.startedAt).Round(time.Second).String(),
+		RequestInterval: s.currencyRequestInterval.String(),
+		Schedule:        s.jobSchedule().String(),
+		Leader:          s.IsLeader(),
+		LastSuccessAt:   lastSuccessAt,
+	}
+
+	if next := s.NextRun(); !next.IsZero() {
+		st.NextRunAt = &next
+	}
+
+	if lastError, err := s.requestStatus.GetLatestByStatus("failure"); err == nil {
+		st.LastError = &lastError
+	}
+
+	if s.database != nil {
+		stats := s.database.Stats()
+
+		st.Database = &databaseStats{
+			MaxOpenConnections: stats.MaxOpenConnections,
+			OpenConnections:    stats.OpenConnections,
+			InUse:              stats.InUse,
+			Idle:               stats.Idle,
+			WaitCount:          stats.WaitCount,
+			WaitDuration:       stats.WaitDuration.String(),
+		}
+	}
+
+	routes.WriteJSON(w, rd.statusCode(), st)
+}
+
+// checkReadiness checks the database and the freshness of the provider job, it returns the time of the
+// last successful request if there is one.
+func (s *Server) checkReadiness(ctx context.Context) (readiness, *time.Time) {
+	rd := readiness{Status: checkOK, Checks: map[string]healthCheck{}}
+
+	if s.database != nil {
+		rd.Checks["database"] = s.checkDatabase(ctx)
+	}
+
+	provider, lastSuccessAt := s.checkProvider()
+	rd.Checks["provider"] = provider
+
+	for _, c := range rd.Checks {
+		if c.Status != checkOK {
+			rd.Status = checkFail
+		}
+	}
+
+	return rd, lastSuccessAt
+}
+
+// checkDatabase pings the database.
+func (s *Server) checkDatabase(ctx context.Context) healthCheck {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	if err := s.database.Ping(ctx); err != nil {
+		return healthCheck{Status: checkFail, Error: err.Error()}
+	}
+
+	return healthCheck{Status: checkOK}
+}
+
+// checkProvider checks that the last successful request to the Currency Provider was made within the
+// last freshnessFactor planned requests, a new instance has the same time to make its first request. A
+// request with the same rates of the last stored snapshot is successful too.
+func (s *Server) checkProvider() (healthCheck, *time.Time) {
+	now := time.Now()
+	quota := s.latestQuota()
+
+	last, err := s.lastSuccess()
+	if err == repository.ErrNotFound {
+		if !now.After(s.staleAt(s.startedAt, quota)) {
+			return healthCheck{Status: checkOK}, nil
+		}
+
+		return healthCheck{Status: checkFail, Error: "there is no successful request to the Currency Provider"}, nil
+	}
+
+	if err != nil {
+		return healthCheck{Status: checkFail, Error: err.Error()}, nil
+	}
+
+	if now.After(s.staleAt(last.RequestedAt, quota)) {
+		return healthCheck{
+			Status: checkFail,
+			Error:  fmt.Sprintf("the last successful request to the Currency Provider was %s ago", now.Sub(last.RequestedAt).Round(time.Second)),
+		}, &last.RequestedAt
+	}
+
+	return healthCheck{Status: checkOK}, &last.RequestedAt
+}
+
+// latestQuota returns the quota reported by the latest request to the Currency Provider, it is read from
+// the repository so every replica plans the requests like the leader. It is nil when the latest request
+// has no quota or it can't be read, then the requests follow the schedule.
+func (s *Server) latestQuota() *providers.Quota {
+	reqs, err := s.requestStatus.List(repository.RequestStatusFilter{Limit: 1})
+	if err != nil || len(reqs) == 0 || reqs[0].QuotaRemaining == nil {
+		return nil
+	}
+
+	quota := &providers.Quota{Remaining: *reqs[0].QuotaRemaining}
+
+	if reqs[0].QuotaLimit != nil {
+		quota.Limit = *reqs[0].QuotaLimit
+	}
+
+	return quota
+}
+
+// lastSuccess returns the latest request that stored new rates or found the rates unchanged.
+func (s *Server) lastSuccess() (repository.RequestStatus, error) {
+	last, err := s.requestStatus.GetLatestByStatus("success")
+	if err != nil && err != repository.ErrNotFound {
+		return last, err
+	}
+
+	unchanged, errUnchanged := s.requestStatus.GetLatestByStatus(statusUnchanged)
+	if errUnchanged == repository.ErrNotFound {
+		return last, err
+	}
+
+	if errUnchanged != nil {
+		return unchanged, errUnchanged
+	}
+
+	if err == repository.ErrNotFound || unchanged.RequestedAt.After(last.RequestedAt) {
+		return unchanged, nil
+	}
+
+	return last, nil
+}
+
+// statusCode returns the http status code of the readiness.
+func (rd readiness) statusCode() int {
+	if rd.Status != checkOK {
+		return http.StatusServiceUnavailable
+	}
+
+	return http.StatusOK
+}
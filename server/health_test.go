@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PacoDw/currency/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// downDatabase is a database which never answers the ping.
+type downDatabase struct{}
+
+func (downDatabase) Ping(ctx context.Context) error { return errors.New("connection refused") }
+func (downDatabase) Stats() sql.DBStats             { return sql.DBStats{OpenConnections: 1} }
+
+func TestHealthRoutes(t *testing.T) {
+	s, repo, closeFn := newTestServer(t, latestPayload)
+	defer closeFn()
+
+	s.WithOptions(Database(repo))
+
+	serve := func(path string) (int, status) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, http.NoBody))
+
+		var st status
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &st))
+
+		return w.Code, st
+	}
+
+	t.Run("healthz", func(t *testing.T) {
+		code, st := serve("/healthz")
+
+		assert.EqualValues(t, http.StatusOK, code)
+		assert.EqualValues(t, "ok", st.Status)
+	})
+
+	t.Run("ready while waiting for the first request", func(t *testing.T) {
+		code, st := serve("/readyz")
+
+		assert.EqualValues(t, http.StatusOK, code)
+		assert.EqualValues(t, "ok", st.Checks["database"].Status)
+		assert.EqualValues(t, "ok", st.Checks["provider"].Status)
+	})
+
+	t.Run("not ready without a fresh request", func(t *testing.T) {
+		s.startedAt = time.Now().Add(-time.Minute)
+
+		code, st := serve("/readyz")
+
+		assert.EqualValues(t, http.StatusServiceUnavailable, code)
+		assert.EqualValues(t, "fail", st.Status)
+		assert.EqualValues(t, "there is no successful request to the Currency Provider", st.Checks["provider"].Error)
+
+		_, err := repo.RequestStatus.Insert(repository.RequestStatus{Status: "success", RequestedAt: time.Now().Add(-time.Second)})
+		assert.Nil(t, err)
+
+		code, st = serve("/readyz")
+
+		assert.EqualValues(t, http.StatusServiceUnavailable, code)
+		assert.Contains(t, st.Checks["provider"].Error, "the last successful request to the Currency Provider was 1s ago")
+	})
+
+	t.Run("status", func(t *testing.T) {
+		s.requestCurrencyProvider(context.Background())
+
+		_, err := repo.RequestStatus.Insert(repository.RequestStatus{URL: "http://provider", Status: "failure", RequestedAt: time.Now()})
+		assert.Nil(t, err)
+
+		code, st := serve("/status")
+
+		assert.EqualValues(t, http.StatusOK, code)
+		assert.EqualValues(t, "ok", st.Status)
+		assert.EqualValues(t, "50ms", st.RequestInterval)
+		assert.NotNil(t, st.LastSuccessAt)
+		assert.NotNil(t, st.Database)
+
+		if assert.NotNil(t, st.LastError) {
+			assert.EqualValues(t, "http://provider", st.LastError.URL)
+		}
+	})
+
+	t.Run("database down", func(t *testing.T) {
+		s.WithOptions(Database(downDatabase{}))
+
+		code, st := serve("/status")
+
+		assert.EqualValues(t, http.StatusServiceUnavailable, code)
+		assert.EqualValues(t, "connection refused", st.Checks["database"].Error)
+		assert.EqualValues(t, 1, st.Database.OpenConnections)
+	})
+}
+
+func TestHealthRoutesQuotaExhausted(t *testing.T) {
+	s, repo, closeFn := newTestServer(t, latestPayload)
+	defer closeFn()
+
+	serve := func() int {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", http.NoBody))
+
+		return w.Code
+	}
+
+	// the last success was an hour ago, the requests are planned every 50ms
+	_, err := repo.RequestStatus.Insert(repository.RequestStatus{Status: "success", RequestedAt: time.Now().Add(-time.Hour)})
+	assert.Nil(t, err)
+
+	assert.EqualValues(t, http.StatusServiceUnavailable, serve())
+
+	// the job is paused until the quota is reset, so every replica is still ready
+	limit, remaining := int64(300), int64(0)
+
+	_, err = repo.RequestStatus.Insert(repository.RequestStatus{Status: "failure", RequestedAt: time.Now(), QuotaLimit: &limit, QuotaRemaining: &remaining})
+	assert.Nil(t, err)
+
+	assert.EqualValues(t, http.StatusOK, serve())
+}
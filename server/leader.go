@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// lockTimeout limits the time taking or releasing the leader lock.
+const lockTimeout = 2 * time.Second
+
+// IsLeader tells if this replica makes the requests to the Currency Provider, it is always true
+// without the server.LeaderElection option.
+func (s *Server) IsLeader() bool {
+	if s.locker == nil {
+		return true
+	}
+
+	s.job.mu.RLock()
+	defer s.job.mu.RUnlock()
+
+	return s.job.leader
+}
+
+// lead tries to take the leader lock before each run, an error taking the lock is handled as a lost
+// leadership so two replicas never make the request at the same time.
+func (s *Server) lead(ctx context.Context) bool {
+	if s.locker == nil {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, lockTimeout)
+	defer cancel()
+
+	leader, err := s.locker.TryLock(ctx)
+	if err != nil {
+		s.logger.Warn("Failed to take the leader lock", zap.Error(err))
+
+		leader = false
+	}
+
+	s.setLeader(leader)
+
+	return leader
+}
+
+// resign releases the leader lock so another replica takes over on its next run.
+func (s *Server) resign() {
+	if s.locker == nil || !s.IsLeader() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lockTimeout)
+	defer cancel()
+
+	if err := s.locker.Unlock(ctx); err != nil {
+		s.logger.Warn("Failed to release the leader lock", zap.Error(err))
+	}
+
+	s.setLeader(false)
+}
+
+// setLeader sets the leadership of this replica and logs when it changes.
+func (s *Server) setLeader(leader bool) {
+	s.job.mu.Lock()
+	changed := s.job.leader != leader
+	s.job.leader = leader
+	s.job.mu.Unlock()
+
+	s.metrics.SetLeader(leader)
+
+	if !changed {
+		return
+	}
+
+	if leader {
+		s.logger.Info("This replica is the leader of the Currency Provider job")
+	} else {
+		s.logger.Info("This replica is not the leader of the Currency Provider job anymore")
+	}
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PacoDw/currency/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// sharedLock is an advisory lock shared by the replicas, each replica has its own sessionLock.
+type sharedLock struct {
+	mu     sync.Mutex
+	holder *sessionLock
+}
+
+// sessionLock represents the session of one replica, a dead session loses the lock.
+type sessionLock struct {
+	shared *sharedLock
+	dead   bool
+}
+
+func (l *sessionLock) TryLock(ctx context.Context) (bool, error) {
+	l.shared.mu.Lock()
+	defer l.shared.mu.Unlock()
+
+	if l.dead {
+		return false, errors.New("connection refused")
+	}
+
+	if l.shared.holder == nil || l.shared.holder.dead {
+		l.shared.holder = l
+	}
+
+	return l.shared.holder == l, nil
+}
+
+func (l *sessionLock) Unlock(ctx context.Context) error {
+	l.shared.mu.Lock()
+	defer l.shared.mu.Unlock()
+
+	if l.shared.holder == l {
+		l.shared.holder = nil
+	}
+
+	return nil
+}
+
+// kill closes the session, so the lock is released like postgres does.
+func (l *sessionLock) kill() {
+	l.shared.mu.Lock()
+	defer l.shared.mu.Unlock()
+
+	l.dead = true
+}
+
+func TestLeaderElection(t *testing.T) {
+	shared := &sharedLock{}
+	first, second := &sessionLock{shared: shared}, &sessionLock{shared: shared}
+
+	s1, repo, closeFn := newTestServer(t, latestPayload)
+	defer closeFn()
+
+	s2, _, closeFn2 := newTestServer(t, latestPayload)
+	defer closeFn2()
+
+	// both replicas share the same database
+	s1.WithOptions(LeaderElection(first))
+	s2.WithOptions(Repository(repo.RequestStatus, repo.CurrencyValue), LeaderElection(second))
+
+	countRequests := func() int {
+		l, err := repo.RequestStatus.List(repository.RequestStatusFilter{Limit: 1000})
+		assert.Nil(t, err)
+
+		return len(l)
+	}
+
+	assert.False(t, s1.IsLeader())
+	assert.True(t, s1.lead(context.Background()))
+	assert.False(t, s2.lead(context.Background()))
+	assert.True(t, s1.IsLeader())
+	assert.False(t, s2.IsLeader())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go s1.RunProviderJob(ctx)
+	go s2.RunProviderJob(ctx)
+
+	assert.Eventually(t, func() bool { return countRequests() >= 3 }, 3*time.Second, 10*time.Millisecond)
+	assert.True(t, s1.IsLeader())
+	assert.False(t, s2.IsLeader())
+
+	// the leader dies and the other replica takes over on its next run
+	first.kill()
+
+	assert.Eventually(t, func() bool { return s2.IsLeader() && !s1.IsLeader() }, 3*time.Second, 10*time.Millisecond)
+
+	s1.quitCurrency <- struct{}{}
+	s2.quitCurrency <- struct{}{}
+
+	// the lock is released when the job is closed
+	assert.Eventually(t, func() bool {
+		shared.mu.Lock()
+		defer shared.mu.Unlock()
+
+		return shared.holder == nil && !s2.IsLeader()
+	}, 3*time.Second, 10*time.Millisecond)
+
+	// without the option every replica is the leader
+	s3, _, closeFn3 := newTestServer(t, latestPayload)
+	defer closeFn3()
+
+	assert.True(t, s3.IsLeader())
+}
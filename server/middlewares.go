@@ -5,16 +5,57 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/PacoDw/currency/iso4217"
+	"github.com/PacoDw/currency/repository"
 	"github.com/PacoDw/currency/routes"
 	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
 )
 
-// ValidateRouteParametersMiddleware validates that the incoming request has the proper route parameters
-// if not it is descarted.
-func ValidateRouteParametersMiddleware(rps []routes.RouteParameter) func(next http.Handler) http.Handler {
+// currencyCodeRegexp matches a currency code, the codes stored by the provider job could be longer than 3
+// letters, e.g.: the crypto currencies.
+var currencyCodeRegexp = regexp.MustCompile("^[A-Za-z]{3,10}$")
+
+// StoredCurrency tells if the currency code is stored, e.g.: BTC returned by the Currency Provider, so
+// the codes which are not ISO 4217 can be read too.
+type StoredCurrency func(code string) (bool, error)
+
+// validCurrencyCode checks that the code is an ISO 4217 currency code or a code already stored, the
+// stored codes are only checked when stored is set.
+func validCurrencyCode(code string, stored StoredCurrency) (bool, error) {
+	if !currencyCodeRegexp.MatchString(code) {
+		return false, nil
+	}
+
+	if _, ok := iso4217.Lookup(code); ok {
+		return true, nil
+	}
+
+	if stored == nil {
+		return false, nil
+	}
+
+	return stored(strings.ToUpper(code))
+}
+
+// IsStoredCurrency tells if the provider job stored a value of the currency, it is the StoredCurrency
+// of the currency middlewares.
+func (s *Server) IsStoredCurrency(code string) (bool, error) {
+	vals, err := s.currencyValue.ListLatestCurrencies(code)
+	if err != nil {
+		return false, err
+	}
+
+	return len(vals) > 0, nil
+}
+
+// ValidateRouteParametersMiddleware validates that the incoming request has the proper route parameters,
+// they must be an ISO 4217 currency code, a stored currency code or 'all', if not it is descarted.
+func ValidateRouteParametersMiddleware(rps []routes.RouteParameter, stored StoredCurrency) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
@@ -32,23 +73,25 @@ func ValidateRouteParametersMiddleware(rps []routes.RouteParameter) func(next ht
 					return
 				}
 
-				// check if the route parameter not contains 3 letters
-				if len(rp) != 3 {
-					w.WriteHeader(http.StatusBadRequest)
+				// check if the route parameter is a known currency code, 'all' selects every currency
+				// so it takes precedence over the Albanian Lek (ALL)
+				if !strings.EqualFold(rp, "all") {
+					ok, err := validCurrencyCode(rp, stored)
+					if err != nil {
+						w.WriteHeader(http.StatusInternalServerError)
 
-					w.Write([]byte(fmt.Sprintf(`{"error":"bad route parameter (%s) with value (%s). it must contain only 3 letters"}`, rps[i], rp)))
+						w.Write([]byte(fmt.Sprintf(`{"error":"failed to check the route parameter (%s) with value (%s)"}`, rps[i], rp)))
 
-					return
-				}
+						return
+					}
 
-				// check if the route parameter contains any number
-				containsNumber, err := regexp.MatchString("[0-9]+", string(rp))
-				if containsNumber || err != nil {
-					w.WriteHeader(http.StatusBadRequest)
+					if !ok {
+						w.WriteHeader(http.StatusBadRequest)
 
-					w.Write([]byte(fmt.Sprintf(`{"error":"bad route parameter (%s) with value (%s). it must contains a number or is invalid"}`, rps[i], rp)))
+						w.Write([]byte(fmt.Sprintf(`{"error":"bad route parameter (%s) with value (%s). it must be an ISO 4217 or a stored currency code"}`, rps[i], rp)))
 
-					return
+						return
+					}
 				}
 
 				// save the current route parameter in upper case
@@ -94,3 +137,165 @@ func ValidateDateTimeQueryParametersMiddleware(qrps []routes.DateTimeQueryParame
 		return http.HandlerFunc(fn)
 	}
 }
+
+// ValidateCurrencyQueryParametersMiddleware validates that the incoming request has the proper currency query
+// parameters, they must be an ISO 4217 currency code or a stored currency code, if not it is descarted.
+func ValidateCurrencyQueryParametersMiddleware(qps []routes.CurrencyQueryParameter, stored StoredCurrency) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			// Validate that all currency query parameters are passed correctly
+			for i := range qps {
+				v := r.URL.Query().Get(string(qps[i]))
+
+				// check if the query parameter is empty
+				if v == "" {
+					w.WriteHeader(http.StatusBadRequest)
+
+					w.Write([]byte(fmt.Sprintf(`{"error":"the query parameter is empty (%s)"}`, qps[i])))
+
+					return
+				}
+
+				// check if the query parameter is a known currency code
+				ok, err := validCurrencyCode(v, stored)
+				if err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+
+					w.Write([]byte(fmt.Sprintf(`{"error":"failed to check the query parameter (%s) with value (%s)"}`, qps[i], v)))
+
+					return
+				}
+
+				if !ok {
+					w.WriteHeader(http.StatusBadRequest)
+
+					w.Write([]byte(fmt.Sprintf(`{"error":"bad query parameter (%s) with value (%s). it must be an ISO 4217 or a stored currency code"}`, qps[i], v)))
+
+					return
+				}
+
+				// save the current query parameter in upper case
+				ctx = context.WithValue(ctx, qps[i], strings.ToUpper(v))
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// ValidateAmountQueryParameterMiddleware validates that the incoming request has a proper amount query
+// parameter, it must be an exact decimal greater or equal than zero, if not it is descarted.
+func ValidateAmountQueryParameterMiddleware(qp routes.AmountQueryParameter) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			v := r.URL.Query().Get(string(qp))
+
+			amount, err := decimal.NewFromString(v)
+			if err != nil || amount.IsNegative() {
+				w.WriteHeader(http.StatusBadRequest)
+
+				w.Write([]byte(fmt.Sprintf(`{"error":"bad query parameter (%s) with value (%s). it must be a positive number"}`, qp, v)))
+
+				return
+			}
+
+			// save the current query parameter
+			ctx := context.WithValue(r.Context(), qp, amount)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// ValidateBucketQueryParameterMiddleware validates that the bucket query parameter, when it is passed,
+// is one of the supported sizes: 1h, 1d or 1w, if not it is descarted.
+func ValidateBucketQueryParameterMiddleware(qp routes.BucketQueryParameter) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			bucket := repository.Bucket(r.URL.Query().Get(string(qp)))
+
+			if bucket != "" && !bucket.Valid() {
+				w.WriteHeader(http.StatusBadRequest)
+
+				w.Write([]byte(fmt.Sprintf(`{"error":"bad query parameter (%s) with value (%s). it must be 1h, 1d or 1w"}`, qp, bucket)))
+
+				return
+			}
+
+			// save the current query parameter
+			ctx := context.WithValue(r.Context(), qp, bucket)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// ValidatePaginationQueryParametersMiddleware validates that the pagination query parameters, when they
+// are passed, are integers greater or equal than zero, if not it is descarted.
+func ValidatePaginationQueryParametersMiddleware(qps []routes.PaginationQueryParameter) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			for i := range qps {
+				v := r.URL.Query().Get(string(qps[i]))
+
+				n := 0
+
+				if v != "" {
+					var err error
+
+					n, err = strconv.Atoi(v)
+					if err != nil || n < 0 {
+						w.WriteHeader(http.StatusBadRequest)
+
+						w.Write([]byte(fmt.Sprintf(`{"error":"bad query parameter (%s) with value (%s). it must be a positive integer"}`, qps[i], v)))
+
+						return
+					}
+				}
+
+				// save the current query parameter
+				ctx = context.WithValue(ctx, qps[i], n)
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// ValidateIDRouteParameterMiddleware validates that the route parameter is an id greater than zero, if
+// not it is descarted.
+func ValidateIDRouteParameterMiddleware(rp routes.RouteParameter) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			v := chi.URLParam(r, string(rp))
+
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || id < 1 {
+				w.WriteHeader(http.StatusBadRequest)
+
+				w.Write([]byte(fmt.Sprintf(`{"error":"bad route parameter (%s) with value (%s). it must be a positive integer"}`, rp, v)))
+
+				return
+			}
+
+			// save the current route parameter
+			ctx := context.WithValue(r.Context(), rp, id)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
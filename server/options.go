@@ -1,6 +1,7 @@
 package server
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -16,8 +17,13 @@ import (
 type FuncOptionType int
 
 const (
-	CURRENCYPROVIDER FuncOptionType = iota
+	REPOSITORY FuncOptionType = iota
+	DATABASE
+	CURRENCYPROVIDER
 	CURRENCYREQUESTINTERVAL
+	SCHEDULE
+	LEADERELECTION
+	RETENTION
 	LISTENON
 	LOGGER
 	MIDLEWARES
@@ -48,21 +54,56 @@ func (f optionFunc) Key() FuncOptionType {
 	return f.key
 }
 
-// Repository allows to set a provider.
-func Repository(repo *repository.SQLConnection) Option {
-	if repo == nil {
-		panic("the currency option must not be nil")
+// Repository allows to set the repositories used by the server, they could be the services of a
+// repository.SQLConnection or a repository.MemoryConnection.
+func Repository(rs repository.RequestStatusRepository, cv repository.CurrencyValueRepository) Option {
+	if rs == nil || cv == nil {
+		panic("the repository option must not be nil")
 	}
 
 	return optionFunc{
-		key: CURRENCYPROVIDER,
+		key: REPOSITORY,
 		callback: func(s *Server) {
-			s.repo = repo
+			s.requestStatus = rs
+			s.currencyValue = cv
 		},
 	}
 }
 
-// CurrencyProvider allows to set a provider.
+// Database allows to set the connection checked by the readiness and status routes, e.g.: a
+// repository.SQLConnection, if it is not set the database is not checked.
+func Database(hc repository.HealthChecker) Option {
+	if hc == nil {
+		panic("the database option must not be nil")
+	}
+
+	return optionFunc{
+		key: DATABASE,
+		callback: func(s *Server) {
+			s.database = hc
+		},
+	}
+}
+
+// LeaderElection allows to run the Currency Provider job in only one replica, the replica holding the
+// lock is the leader and the others skip their requests trying to take the lock on each planned run, so
+// another replica takes over within one run when the leader dies. It could be a repository.SQLConnection
+// which holds a Postgres advisory lock. Without this option every replica makes its own requests.
+func LeaderElection(l repository.Locker) Option {
+	if l == nil {
+		panic("the leader election option must not be nil")
+	}
+
+	return optionFunc{
+		key: LEADERELECTION,
+		callback: func(s *Server) {
+			s.locker = l
+		},
+	}
+}
+
+// CurrencyProvider allows to set a provider, to set more than one provider wrap them with
+// providers.NewFallbackProvider which tries them in order.
 func CurrencyProvider(currency providers.Currencier) Option {
 	if currency == nil {
 		panic("the currency option must not be nil")
@@ -84,6 +125,10 @@ func CurrencyRequestInterval(requestInterval string) Option {
 		key: CURRENCYPROVIDER,
 		callback: func(s *Server) {
 			d, err := time.ParseDuration(requestInterval)
+			if err == nil && d <= 0 {
+				err = errors.New("the interval must be positive")
+			}
+
 			if err != nil {
 				s.logger.Warn("the requestInterval is not correct using default value (10s)",
 					zap.String("requestInterval", requestInterval),
@@ -98,6 +143,49 @@ func CurrencyRequestInterval(requestInterval string) Option {
 	}
 }
 
+// CronSchedule allows to set when the Currency Provider is called using cron expressions instead of a
+// fixed interval, the next request is the earliest of all the expressions. The expressions accept
+// optional seconds, the descriptors like @hourly and the timezone with the CRON_TZ= prefix, e.g.:
+// "CRON_TZ=America/New_York */15 9-16 * * MON-FRI", "CRON_TZ=America/New_York 0 * * * SAT,SUN".
+// The empty expressions are skipped, if all of them are empty the request interval is used.
+func CronSchedule(specs ...string) Option {
+	cs, err := parseCronSchedule(specs...)
+	if err != nil {
+		panic(err)
+	}
+
+	return optionFunc{
+		key: SCHEDULE,
+		callback: func(s *Server) {
+			if len(cs.schedules) == 0 {
+				return
+			}
+
+			s.schedule = cs
+		},
+	}
+}
+
+// Retention allows to roll up the currency values older than the policy into hourly and daily
+// aggregates, the rollups are made by the leader every hour and the read routes merge them with the
+// raw values. The option panics when the policy is invalid and a disabled policy keeps all the values.
+func Retention(p RetentionPolicy) Option {
+	if err := p.validate(); err != nil {
+		panic(err)
+	}
+
+	return optionFunc{
+		key: RETENTION,
+		callback: func(s *Server) {
+			if !p.Enabled() {
+				return
+			}
+
+			s.retention = &p
+		},
+	}
+}
+
 // ListenOn optionally specifies the TCP address for the server to listen on,
 // in the form "host:port". If empty, ":http" (port 9000) is used.
 // The service names are defined in RFC 6335 and assigned by IANA.
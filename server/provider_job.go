@@ -2,109 +2,303 @@ package server
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
+	"github.com/PacoDw/currency/providers"
 	"github.com/PacoDw/currency/repository"
 	"github.com/spf13/cast"
 	"go.uber.org/zap"
 )
 
+// statusUnchanged is the status of a request whose rates are the same as the last stored snapshot, the
+// request is registered but the rates are not stored again.
+const statusUnchanged = "unchanged"
+
+// statusBackfill is the status of a request of the historical exchange rates made by a backfill, its
+// rates are stored but it doesn't tell that the provider job is working.
+const statusBackfill = "backfill"
+
 // RunProviderJob is a job that will run during all the life cicle of the server making request
 // to the Currency Provider, the configuration is determinated by the server config.
-// Note: every N time the job is going to make a request to the Currency Provider but this request
-// is limited by a timeout, if the timeout is reached the request will be cancelled.
+// Note: the first request is made when the job starts and the next ones follow the schedule, which is
+// the cron schedule or every N time, but each request is limited by a timeout, if the timeout is
+// reached the request will be cancelled.
 func (s *Server) RunProviderJob(ctx context.Context) {
-	s.logger.Info(fmt.Sprintf("Running Currency Provider each %s", s.currencyRequestInterval))
+	s.logger.Info(fmt.Sprintf("Running Currency Provider with the schedule %s", s.jobSchedule()))
+
+	// the age of the newest rate starts from the data already stored
+	if _, fend, err := s.currencyValue.GetFinitAndFend(); err != nil {
+		s.logger.Warn(fmt.Sprintf("error trying to get the newest rate: %s", err))
+	} else if !fend.IsZero() {
+		s.metrics.SetNewestRate(fend)
+	}
 
-	ticker := time.NewTicker(s.currencyRequestInterval)
-	defer ticker.Stop()
+	// the first request is made right away
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	s.setNextRun(time.Now())
 
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	done := make(chan struct{})
+
 	// starts the job by runing infinite loop
 	go func() {
+		defer close(done)
+
 		for {
 			select {
 			case <-ctx.Done():
 				log.Println("context is finishing the Currency Provider")
 
 				return
-			case <-ticker.C:
-				// getting the latest data from the Currency Provider
-				meta, blob, errReq := s.currencyClient.GetLatestExchangeRates(ctx)
-
-				// logging the stats
-				s.logger.Info("Request",
-					zap.String("url", cast.ToString(meta.URL)),
-					zap.String("time_elapsed", cast.ToString(meta.Elapsed)),
-					zap.String("status", cast.ToString(meta.Status)),
-					zap.String("requested_at", cast.ToString(meta.RequestedAt.Format(time.RFC3339))),
-					zap.String("details", cast.ToString(errReq)),
-				)
-
-				// creating the stats to be saved in the database
-				reqStats := repository.RequestStatus{
-					URL:         meta.URL,
-					TimeElapsed: meta.Elapsed,
-					Status:      meta.Status,
-					RequestedAt: meta.RequestedAt,
-				}
+			case <-timer.C:
+				var meta *providers.Metadata
 
-				// checking the database connection and saving the stats into the database
-				requestID, err := s.repo.CheckConn().RequestStatus.Insert(reqStats)
-				if err != nil {
-					s.logger.Warn(fmt.Sprintf("error trying to insert request status: %s", err))
+				// only the leader makes the request, the others wait for the next run
+				if s.lead(ctx) {
+					meta = s.requestCurrencyProvider(ctx)
 				}
 
-				// if there is an error or the request to the Currency Provider was failed then
-				// cancel the current request and wait for the next one according to the Request
-				// Interval
-				if reqStats.Status == "failure" || errReq != nil {
-					continue
-				}
+				s.refreshNewestRate()
 
-				// so far the previous requst was successed then we need to map the data
-				cvals := make([]repository.CurrencyValue, 0)
+				next := s.planNextRun(time.Now(), meta)
+				s.setNextRun(next)
 
-				rootM := map[string]interface{}{}
-				if err := json.Unmarshal(blob, &rootM); err != nil {
-					s.logger.Warn(fmt.Sprintf("error trying to unmarshall: %s", err))
+				s.logger.Info("Next request to the Currency Provider", zap.String("next_run_at", next.Format(time.RFC3339)))
 
-					return
-				}
+				timer.Reset(time.Until(next))
+			}
+		}
+	}()
 
-				lastUpdated, err := time.Parse("2006-01-02T15:04:05Z", rootM["meta"].(map[string]interface{})["last_updated_at"].(string))
-				if err != nil {
-					log.Printf("err time.Parse: %#+v\n", err)
+	<-s.quitCurrency
 
-					return
-				}
+	// the loop is stopped before releasing the lock so it is not taken again
+	cancel()
+	<-done
+	s.resign()
 
-				data := rootM["data"].(map[string]interface{})
+	log.Println("Currency Provider Job is closed successfully")
+}
 
-				for _, val := range data {
-					cm := val.(map[string]interface{})
+// refreshNewestRate registers the newest rate inserted by any replica, so the age of the newest rate
+// doesn't grow forever on the replicas which are not the leader.
+func (s *Server) refreshNewestRate() {
+	last, err := s.currencyValue.GetLastInsertedAt()
+	if err != nil {
+		s.logger.Warn(fmt.Sprintf("error trying to get the newest rate: %s", err))
 
-					cvals = append(cvals, repository.CurrencyValue{
-						Name:         cast.ToString(cm["code"]),
-						RequestID:    requestID,
-						Value:        cast.ToFloat64(cm["value"]),
-						LastUdatedAt: lastUpdated,
-					})
-				}
+		return
+	}
 
-				// Insert the data in baches into the database
-				if err := s.repo.CheckConn().CurrencyValue.BulkInsert(cvals); err != nil {
-					s.logger.Warn(fmt.Sprintf("error make a bulkinsert: %s", err))
-				}
-			}
+	if !last.IsZero() {
+		s.metrics.SetNewestRate(last)
+	}
+}
+
+// planNextRun returns the time of the next request according to the schedule, it is delayed when the
+// quota of the Currency Provider would be exhausted. If the schedule has no next run, e.g.: every cron
+// expression stopped running, it backs off to the request interval instead of requesting right away.
+func (s *Server) planNextRun(now time.Time, meta *providers.Metadata) time.Time {
+	next := now.Add(s.nextInterval(now, meta))
+	if next.After(now) {
+		return next
+	}
+
+	s.logger.Error("the schedule of the Currency Provider has no next run, waiting the request interval",
+		zap.String("schedule", s.jobSchedule().String()),
+		zap.String("request_interval", s.currencyRequestInterval.String()),
+	)
+
+	return now.Add(s.currencyRequestInterval)
+}
+
+// requestCurrencyProvider makes one request to the Currency Provider and stores the result, a bad
+// payload is logged and skipped so the job keeps running for the next request. It returns the
+// metadata of the request, which could be nil if the request panicked.
+func (s *Server) requestCurrencyProvider(ctx context.Context) (meta *providers.Metadata) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("the Currency Provider request panicked", zap.Any("panic", r))
 		}
 	}()
 
-	<-s.quitCurrency
-	log.Println("Currency Provider Job is closed successfully")
+	// getting the latest data from the Currency Provider
+	start := time.Now()
+	meta, rates, errReq := s.currencyClient.GetLatestExchangeRates(ctx)
+
+	// the Currency Provider refreshes the rates less often than the requests are made, so the rates of
+	// the last stored snapshot are not stored again
+	if errReq == nil && meta.Status == "success" && s.unchangedSnapshot(meta.Provider, rates) {
+		meta.Status = statusUnchanged
+	}
+
+	// the error is already logged, the job waits for the next run
+	_ = s.storeRequest(start, meta, rates, errReq)
+
+	return meta
+}
+
+// storeRequest stores the stats of the request to the Currency Provider and its rates if it succeeded,
+// it returns an error when the rates are not stored.
+func (s *Server) storeRequest(start time.Time, meta *providers.Metadata, rates []providers.Rate, errReq error) error {
+	// logging the stats
+	s.logger.Info("Request",
+		zap.String("provider", meta.Provider),
+		zap.String("url", cast.ToString(meta.URL)),
+		zap.String("time_elapsed", cast.ToString(meta.Elapsed)),
+		zap.String("status", cast.ToString(meta.Status)),
+		zap.String("requested_at", cast.ToString(meta.RequestedAt.Format(time.RFC3339))),
+		zap.Int("attempts", meta.Attempts),
+		zap.Int("status_code", meta.StatusCode),
+		zap.Int64("response_bytes", meta.ResponseBytes),
+		zap.String("details", cast.ToString(errReq)),
+	)
+
+	// creating the stats to be saved in the database
+	reqStats := repository.RequestStatus{
+		URL:           meta.URL,
+		TimeElapsed:   meta.Elapsed,
+		Status:        meta.Status,
+		RequestedAt:   meta.RequestedAt,
+		Provider:      meta.Provider,
+		StatusCode:    meta.StatusCode,
+		ResponseBytes: meta.ResponseBytes,
+		ErrorMessage:  meta.ErrorMessage(),
+	}
+
+	if meta.Quota != nil {
+		reqStats.QuotaLimit = &meta.Quota.Limit
+		reqStats.QuotaRemaining = &meta.Quota.Remaining
+	}
+
+	// saving the stats into the database, the values can't be stored without their request
+	requestID, err := s.requestStatus.Insert(reqStats)
+	if err != nil {
+		s.logger.Warn(fmt.Sprintf("error trying to insert request status: %s", err))
+
+		return err
+	}
+
+	// if there is an error or the request to the Currency Provider was failed then
+	// skip the current request and wait for the next one according to the Request
+	// Interval
+	if reqStats.Status == "failure" || errReq != nil {
+		s.metrics.ObserveProviderRequest(meta.Provider, "failure", time.Since(start))
+
+		if errReq == nil {
+			errReq = errors.New("the request to the Currency Provider failed")
+		}
+
+		return errReq
+	}
+
+	if reqStats.Status == statusUnchanged {
+		s.metrics.ObserveProviderRequest(meta.Provider, statusUnchanged, time.Since(start))
+
+		return nil
+	}
+
+	s.metrics.ObserveProviderRequest(meta.Provider, reqStats.Status, time.Since(start))
+
+	// so far the previous requst was successed then we need to map the data
+	cvals := make([]repository.CurrencyValue, 0, len(rates))
+
+	for i := range rates {
+		cvals = append(cvals, repository.CurrencyValue{
+			Name:         rates[i].Code,
+			RequestID:    requestID,
+			Provider:     meta.Provider,
+			Value:        rates[i].Value,
+			LastUdatedAt: rates[i].LastUpdatedAt,
+		})
+	}
+
+	// Insert the data in baches into the database, the values already stored are skipped
+	start = time.Now()
+	inserted, skipped, err := s.currencyValue.BulkInsert(cvals)
+
+	s.metrics.ObserveBulkInsert(len(cvals), skipped, time.Since(start), err)
+
+	if err != nil {
+		s.logger.Warn(fmt.Sprintf("error make a bulkinsert: %s", err))
+
+		return err
+	}
+
+	if skipped > 0 {
+		s.logger.Info("Currency values already stored were skipped",
+			zap.String("provider", meta.Provider),
+			zap.Int("inserted", inserted),
+			zap.Int("skipped", skipped),
+		)
+	}
+
+	for i := range cvals {
+		s.metrics.SetNewestRate(cvals[i].LastUdatedAt)
+	}
+
+	return nil
+}
+
+// unchangedSnapshot checks if the rates of the provider have the same currencies, last updated date and
+// values of the snapshot stored by the latest success request, a rate of another provider is a change.
+func (s *Server) unchangedSnapshot(provider string, rates []providers.Rate) bool {
+	if len(rates) == 0 {
+		return false
+	}
+
+	latest, err := s.latestSnapshot()
+	if err != nil {
+		s.logger.Warn(fmt.Sprintf("error trying to get the latest snapshot: %s", err))
+
+		return false
+	}
+
+	if len(latest) != len(rates) {
+		return false
+	}
+
+	stored := make(map[string]repository.CurrencyValue, len(latest))
+	for i := range latest {
+		stored[latest[i].Name] = latest[i]
+	}
+
+	for i := range rates {
+		cv, ok := stored[rates[i].Code]
+		if !ok ||
+			cv.Provider != provider ||
+			!cv.LastUdatedAt.Equal(rates[i].LastUpdatedAt) ||
+			!cv.Value.Equal(rates[i].Value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// latestSnapshot returns the values stored by the latest success request, if its values were already
+// stored by a previous request, e.g.: a retried request, the latest value of each currency is returned.
+func (s *Server) latestSnapshot() ([]repository.CurrencyValue, error) {
+	last, err := s.requestStatus.GetLatestByStatus("success")
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	vals, err := s.currencyValue.ListCurrenciesByRequestID(last.ID)
+	if err != nil || len(vals) > 0 {
+		return vals, err
+	}
+
+	return s.currencyValue.ListLatestCurrencies("all")
 }
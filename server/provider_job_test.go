@@ -2,35 +2,341 @@ package server
 
 import (
 	"context"
-	"os"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
 	"testing"
 	"time"
 
 	"github.com/PacoDw/currency/providers"
 	"github.com/PacoDw/currency/repository"
-	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
 )
 
-func init() {
-	if err := godotenv.Load("../.env"); err != nil {
-		panic(err)
+// latestPayload is a payload of the latest exchange rates of the Free Currency Api.
+const latestPayload = `{
+	"meta": {"last_updated_at": "2022-10-18T23:59:59Z"},
+	"data": {
+		"EUR": {"code": "EUR", "value": 1.0167},
+		"MXN": {"code": "MXN", "value": 20.0512},
+		"USD": {"code": "USD", "value": 1}
 	}
+}`
+
+// newTestProvider creates a Free Currency Api Provider that makes the requests to a server answering
+// with the payload.
+func newTestProvider(t *testing.T, payload string) (providers.Currencier, func()) {
+	t.Helper()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(payload))
+	}))
+
+	u, err := url.Parse(ts.URL)
+	assert.Nil(t, err)
+
+	return providers.NewFreeCurrencyAPI(&providers.CurrencyConfig{
+		URL:     u,
+		APIKey:  "API_KEY",
+		Timeout: time.Second,
+	}), ts.Close
 }
 
-func TestProviderJob(t *testing.T) {
-	repo := repository.NewSQLConnection(repository.DefaultPostgresConfig())
-	currencyProvider := providers.NewFreeCurrencyAPI(providers.DefaultFreeCurrencyAPIConfig())
+// newTestServer creates a server with an in-memory repository and a provider answering with the payload.
+func newTestServer(t *testing.T, payload string) (*Server, *repository.MemoryConnection, func()) {
+	t.Helper()
+
+	repo := repository.NewMemoryConnection()
+	currencyProvider, closeFn := newTestProvider(t, payload)
 
 	s := New(
-		Repository(repo),
+		Repository(repo.RequestStatus, repo.CurrencyValue),
 		CurrencyProvider(currencyProvider),
-		CurrencyRequestInterval(os.Getenv("REQUEST_INTERVAL")),
+		CurrencyRequestInterval("50ms"),
 	)
 
+	return s, repo, closeFn
+}
+
+func TestProviderJob(t *testing.T) {
+	s, repo, closeFn := newTestServer(t, latestPayload)
+	defer closeFn()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go s.RunProviderJob(ctx)
+
+	assert.Eventually(t, func() bool {
+		vals, err := repo.CurrencyValue.ListCurrenciesByDateRange("all", nil, nil)
+
+		return err == nil && len(vals) >= 3
+	}, 3*time.Second, 10*time.Millisecond)
+
+	s.quitCurrency <- struct{}{}
+
+	cr, err := repo.CurrencyValue.GetCrossRate("USD", "MXN", nil)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "20.0512", cr.Rate.String())
+	assert.EqualValues(t, time.Date(2022, 10, 18, 23, 59, 59, 0, time.UTC), cr.AsOf)
+}
+
+func TestProviderJobSkipsBadPayloads(t *testing.T) {
+	s, repo, closeFn := newTestServer(t, `{"meta": {"last_updated_at": 1}, "data": []}`)
+	defer closeFn()
+
+	s.requestCurrencyProvider(context.Background())
+	s.requestCurrencyProvider(context.Background())
+
+	vals, err := repo.CurrencyValue.ListCurrenciesByDateRange("all", nil, nil)
+
+	assert.Nil(t, err)
+	assert.Empty(t, vals)
+
+	// the failed requests are registered anyway
+	id, err := repo.RequestStatus.Insert(repository.RequestStatus{})
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 3, id)
+}
+
+func TestProviderJobMetrics(t *testing.T) {
+	s, _, closeFn := newTestServer(t, latestPayload)
+	defer closeFn()
+
+	s.requestCurrencyProvider(context.Background())
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `currency_provider_requests_total{provider="currencyapi",status="success"} 1`)
+	assert.Contains(t, w.Body.String(), `currency_bulk_insert_rows_total{status="success"} 3`)
+}
+
+func TestProviderJobNewestRateOnFollowers(t *testing.T) {
+	s, repo, closeFn := newTestServer(t, latestPayload)
+	defer closeFn()
+
+	// other replica is the leader
+	shared := &sharedLock{}
+	leader := &sessionLock{shared: shared}
+
+	ok, err := leader.TryLock(context.Background())
+	assert.True(t, ok)
+	assert.Nil(t, err)
+
+	s.WithOptions(LeaderElection(&sessionLock{shared: shared}))
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	go s.RunProviderJob(ctx)
 
-	<-ctx.Done()
+	scrape := func() string {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+
+		return w.Body.String()
+	}
+
+	assert.Contains(t, scrape(), "currency_newest_rate_age_seconds NaN")
+
+	// the rates stored by the leader are read on the next run
+	seedSnapshot(t, repo, time.Now().Add(-time.Hour), map[string]float64{"USD": 1, "MXN": 20})
+
+	assert.Eventually(t, func() bool {
+		return regexp.MustCompile(`currency_newest_rate_age_seconds 36\d\d`).MatchString(scrape())
+	}, 3*time.Second, 10*time.Millisecond)
+
+	assert.False(t, s.IsLeader())
+
+	s.quitCurrency <- struct{}{}
+}
+
+func TestProviderJobSkipsDuplicates(t *testing.T) {
+	s, repo, closeFn := newTestServer(t, latestPayload)
+	defer closeFn()
+
+	meta, rates, err := s.currencyClient.GetLatestExchangeRates(context.Background())
+	assert.Nil(t, err)
+
+	// e.g.: two replicas storing the same request
+	assert.Nil(t, s.storeRequest(time.Now(), meta, rates, nil))
+	assert.Nil(t, s.storeRequest(time.Now(), meta, rates, nil))
+
+	vals, err := repo.CurrencyValue.ListCurrenciesByDateRange("all", nil, nil)
+
+	assert.Nil(t, err)
+	assert.Len(t, vals, 3)
+	assert.EqualValues(t, providers.FreeCurrencyAPIName, vals[0].Provider)
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+
+	assert.Contains(t, w.Body.String(), `currency_bulk_insert_rows_total{status="skipped"} 3`)
+}
+
+// brokenRequestStatus fails to insert like a repository without database, the other methods use the
+// embedded one.
+type brokenRequestStatus struct {
+	repository.RequestStatusRepository
+}
+
+func (brokenRequestStatus) Insert(rs repository.RequestStatus) (int64, error) {
+	return 0, errors.New("dial tcp 127.0.0.1:5432: connect: connection refused")
+}
+
+func TestProviderJobRequestStatusError(t *testing.T) {
+	s, repo, closeFn := newTestServer(t, latestPayload)
+	defer closeFn()
+
+	s.requestStatus = brokenRequestStatus{repo.RequestStatus}
+
+	meta, rates, err := s.currencyClient.GetLatestExchangeRates(context.Background())
+	assert.Nil(t, err)
+
+	// the values are not stored without their request
+	assert.NotNil(t, s.storeRequest(time.Now(), meta, rates, nil))
+
+	vals, err := repo.CurrencyValue.ListCurrenciesByDateRange("all", nil, nil)
+
+	assert.Nil(t, err)
+	assert.Empty(t, vals)
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+
+	assert.NotContains(t, w.Body.String(), `currency_bulk_insert_rows_total{status="failure"}`)
+}
+
+func TestProviderJobUnchangedSnapshot(t *testing.T) {
+	s, repo, closeFn := newTestServer(t, latestPayload)
+	defer closeFn()
+
+	// the rates were not updated by the Currency Provider between both requests
+	s.requestCurrencyProvider(context.Background())
+	meta := s.requestCurrencyProvider(context.Background())
+
+	assert.EqualValues(t, statusUnchanged, meta.Status)
+
+	vals, err := repo.CurrencyValue.ListCurrenciesByDateRange("all", nil, nil)
+
+	assert.Nil(t, err)
+	assert.Len(t, vals, 3)
+
+	rs, err := repo.RequestStatus.GetLatestByStatus(statusUnchanged)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, rs.ID)
+
+	vals, err = repo.CurrencyValue.ListCurrenciesByRequestID(rs.ID)
+
+	assert.Nil(t, err)
+	assert.Empty(t, vals)
+
+	// the unchanged request is fresh for the readiness
+	last, err := s.lastSuccess()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, last.ID)
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+
+	assert.Contains(t, w.Body.String(), `currency_provider_requests_total{provider="currencyapi",status="unchanged"} 1`)
+	assert.Contains(t, w.Body.String(), `currency_bulk_insert_rows_total{status="success"} 3`)
+
+	t.Run("changes", func(t *testing.T) {
+		_, rates, err := s.currencyClient.GetLatestExchangeRates(context.Background())
+		assert.Nil(t, err)
+
+		assert.True(t, s.unchangedSnapshot(providers.FreeCurrencyAPIName, rates))
+		assert.False(t, s.unchangedSnapshot(providers.ECBName, rates))
+
+		updated := append([]providers.Rate{}, rates...)
+		updated[0].LastUpdatedAt = updated[0].LastUpdatedAt.Add(time.Hour)
+
+		assert.False(t, s.unchangedSnapshot(providers.FreeCurrencyAPIName, updated))
+
+		moved := append([]providers.Rate{}, rates...)
+		moved[0].Value = moved[0].Value.Add(decimal.NewFromFloat(0.0001))
+
+		assert.False(t, s.unchangedSnapshot(providers.FreeCurrencyAPIName, moved))
+
+		added := append([]providers.Rate{{Code: "JPY", Value: decimal.NewFromInt(145), LastUpdatedAt: rates[0].LastUpdatedAt}}, rates...)
+
+		assert.False(t, s.unchangedSnapshot(providers.FreeCurrencyAPIName, added))
+
+		// a currency dropped by the provider
+		assert.False(t, s.unchangedSnapshot(providers.FreeCurrencyAPIName, rates[1:]))
+	})
+
+	t.Run("success request without values", func(t *testing.T) {
+		_, rates, err := s.currencyClient.GetLatestExchangeRates(context.Background())
+		assert.Nil(t, err)
+
+		// e.g.: a retried request whose values were already stored
+		_, err = repo.RequestStatus.Insert(repository.RequestStatus{Status: "success", RequestedAt: time.Now()})
+		assert.Nil(t, err)
+
+		assert.True(t, s.unchangedSnapshot(providers.FreeCurrencyAPIName, rates))
+	})
+}
+
+func TestProviderJobRecordsTheResponse(t *testing.T) {
+	s, repo, closeFn := newTestServer(t, `{"message": "Too many requests"}`)
+	defer closeFn()
+
+	s.requestCurrencyProvider(context.Background())
+
+	rs, err := repo.RequestStatus.GetLatestByStatus("failure")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, providers.FreeCurrencyAPIName, rs.Provider)
+	assert.EqualValues(t, http.StatusOK, rs.StatusCode)
+	assert.EqualValues(t, 32, rs.ResponseBytes)
+	assert.EqualValues(t, "the snapshot has no rates", rs.ErrorMessage)
+}
+
+func TestProviderJobQuota(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit-Quota-Month", "300")
+		w.Header().Set("X-RateLimit-Remaining-Quota-Month", "0")
+		w.Write([]byte(latestPayload))
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	assert.Nil(t, err)
+
+	repo := repository.NewMemoryConnection()
+
+	s := New(
+		Repository(repo.RequestStatus, repo.CurrencyValue),
+		CurrencyProvider(providers.NewFreeCurrencyAPI(&providers.CurrencyConfig{URL: u, APIKey: "API_KEY", Timeout: time.Second})),
+		CurrencyRequestInterval("50ms"),
+	)
+
+	meta := s.requestCurrencyProvider(context.Background())
+
+	if assert.NotNil(t, meta.Quota) {
+		assert.EqualValues(t, providers.Quota{Limit: 300, Remaining: 0}, *meta.Quota)
+	}
+
+	// the job is paused because the quota is exhausted
+	assert.Greater(t, int64(s.nextInterval(time.Now(), meta)), int64(time.Minute))
+
+	rs, err := repo.RequestStatus.GetLatestByStatus("success")
+
+	assert.Nil(t, err)
+
+	if assert.NotNil(t, rs.QuotaRemaining) && assert.NotNil(t, rs.QuotaLimit) {
+		assert.EqualValues(t, 0, *rs.QuotaRemaining)
+		assert.EqualValues(t, 300, *rs.QuotaLimit)
+	}
 }
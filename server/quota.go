@@ -0,0 +1,119 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/PacoDw/currency/providers"
+	"go.uber.org/zap"
+)
+
+// quotaLowRatio is the part of the monthly quota left which is considered low, a warning is logged
+// from there on.
+const quotaLowRatio = 0.1
+
+// maxQuotaPause limits how long the job is paused when the quota is exhausted, so a quota which is
+// not reset exactly at the beginning of the month is checked again the next day.
+const maxQuotaPause = 24 * time.Hour
+
+// quotaPlan represents when the next request must be made so the quota is not exhausted.
+type quotaPlan struct {
+	// Interval represents the time to wait before the next request.
+	Interval time.Duration
+
+	// Stretched tells that the interval is longer than the configured one.
+	Stretched bool
+
+	// Skipped represents the runs of the schedule skipped before the next request.
+	Skipped int64
+
+	// Paused tells that the quota is exhausted.
+	Paused bool
+
+	// Low tells that the quota left is less than quotaLowRatio of the limit.
+	Low bool
+}
+
+// planQuota returns the time to wait before the next request of the schedule that makes the remaining quota
+// last until the end of the month, the runs of the schedule until then are counted and if they are more
+// than the remaining quota only one of every few runs is made. The quota is considered to be reset at the
+// beginning of each month in UTC.
+func planQuota(now time.Time, sched schedule, quota *providers.Quota) quotaPlan {
+	next := sched.Next(now)
+	plan := quotaPlan{Interval: next.Sub(now)}
+
+	if quota == nil || !next.After(now) {
+		return plan
+	}
+
+	plan.Low = quota.Limit > 0 && float64(quota.Remaining) < float64(quota.Limit)*quotaLowRatio
+
+	now = now.UTC()
+	monthEnd := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+
+	if quota.Remaining <= 0 {
+		plan.Paused = true
+		plan.Interval = monthEnd.Sub(now)
+
+		if plan.Interval > maxQuotaPause {
+			plan.Interval = maxQuotaPause
+		}
+
+		return plan
+	}
+
+	// the requests that would be made until the end of the month following the schedule
+	var needed int64
+
+	for t := next; !t.IsZero() && t.Before(monthEnd); t = sched.Next(t) {
+		needed++
+	}
+
+	if needed <= quota.Remaining {
+		return plan
+	}
+
+	// e.g.: every other run when the quota covers half of them
+	step := (needed + quota.Remaining - 1) / quota.Remaining
+
+	for i := int64(1); i < step; i++ {
+		next = sched.Next(next)
+	}
+
+	plan.Stretched = true
+	plan.Skipped = step - 1
+	plan.Interval = next.Sub(now)
+
+	return plan
+}
+
+// nextInterval returns the interval until the next request according to the schedule and the quota
+// reported in the metadata, the interval is the scheduled one if the quota is enough. It logs a warning
+// when the quota is low, stretched or exhausted.
+func (s *Server) nextInterval(now time.Time, meta *providers.Metadata) time.Duration {
+	if meta == nil || meta.Quota == nil {
+		return s.jobSchedule().Next(now).Sub(now)
+	}
+
+	plan := planQuota(now, s.jobSchedule(), meta.Quota)
+
+	s.setSkippedRuns(plan.Skipped)
+
+	fields := []zap.Field{
+		zap.String("provider", meta.Provider),
+		zap.Int64("quota_limit", meta.Quota.Limit),
+		zap.Int64("quota_remaining", meta.Quota.Remaining),
+		zap.String("interval", plan.Interval.String()),
+	}
+
+	switch {
+	case plan.Paused:
+		s.logger.Warn(fmt.Sprintf("the Currency Provider quota is exhausted, pausing the requests for %s", plan.Interval), fields...)
+	case plan.Stretched:
+		s.logger.Warn("the Currency Provider quota would be exhausted before the end of the month, stretching the interval", fields...)
+	case plan.Low:
+		s.logger.Warn("the Currency Provider quota is running low", fields...)
+	}
+
+	return plan.Interval
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PacoDw/currency/providers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanQuota(t *testing.T) {
+	// 10 days before the end of the month
+	now := time.Date(2022, 10, 22, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		interval time.Duration
+		quota    *providers.Quota
+		expected quotaPlan
+	}{
+		{
+			name:     "without quota",
+			interval: time.Minute,
+			expected: quotaPlan{Interval: time.Minute},
+		},
+		{
+			name:     "enough quota",
+			interval: time.Hour,
+			quota:    &providers.Quota{Limit: 300, Remaining: 240},
+			expected: quotaPlan{Interval: time.Hour},
+		},
+		{
+			name:     "stretched",
+			interval: time.Hour,
+			quota:    &providers.Quota{Limit: 300, Remaining: 120},
+			expected: quotaPlan{Interval: 2 * time.Hour, Stretched: true, Skipped: 1},
+		},
+		{
+			name:     "stretched and low",
+			interval: time.Hour,
+			quota:    &providers.Quota{Limit: 300, Remaining: 24},
+			expected: quotaPlan{Interval: 10 * time.Hour, Stretched: true, Skipped: 9, Low: true},
+		},
+		{
+			name:     "low",
+			interval: 24 * time.Hour,
+			quota:    &providers.Quota{Limit: 300, Remaining: 20},
+			expected: quotaPlan{Interval: 24 * time.Hour, Low: true},
+		},
+		{
+			name:     "exhausted",
+			interval: time.Hour,
+			quota:    &providers.Quota{Limit: 300, Remaining: 0},
+			expected: quotaPlan{Interval: maxQuotaPause, Paused: true, Low: true},
+		},
+	}
+
+	for i := range tests {
+		tt := tests[i]
+
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualValues(t, tt.expected, planQuota(now, intervalSchedule(tt.interval), tt.quota))
+		})
+	}
+
+	t.Run("exhausted at the end of the month", func(t *testing.T) {
+		end := time.Date(2022, 10, 31, 23, 0, 0, 0, time.UTC)
+
+		plan := planQuota(end, intervalSchedule(time.Hour), &providers.Quota{Limit: 300, Remaining: 0})
+
+		assert.EqualValues(t, time.Hour, plan.Interval)
+		assert.True(t, plan.Paused)
+	})
+
+	t.Run("cron schedule", func(t *testing.T) {
+		// hourly on weekdays, the 22nd is a saturday so 144 runs are left in the month
+		cs, err := parseCronSchedule("CRON_TZ=UTC 0 * * * MON-FRI")
+		assert.Nil(t, err)
+
+		plan := planQuota(now, cs, &providers.Quota{Limit: 300, Remaining: 150})
+
+		assert.EqualValues(t, quotaPlan{Interval: 48 * time.Hour}, plan)
+
+		// every other run is made, the weekend is still skipped
+		plan = planQuota(now, cs, &providers.Quota{Limit: 300, Remaining: 72})
+
+		assert.EqualValues(t, quotaPlan{Interval: 49 * time.Hour, Stretched: true, Skipped: 1}, plan)
+	})
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/PacoDw/currency/repository"
+	"go.uber.org/zap"
+)
+
+// retentionInterval is the time between two rollups of the currency values.
+const retentionInterval = time.Hour
+
+// RetentionPolicy represents how long the currency values are kept at each resolution, the raw values
+// older than RawDays are rolled up into hourly aggregates and the hourly aggregates older than HourlyDays
+// into daily ones, which are kept forever. A zero RawDays disables the retention and a zero HourlyDays
+// keeps the hourly aggregates.
+type RetentionPolicy struct {
+	RawDays    int
+	HourlyDays int
+}
+
+// DefaultEnvRetentionPolicy creates the retention policy from the env variables RETENTION_RAW_DAYS and
+// RETENTION_HOURLY_DAYS, the retention is disabled when they are not set.
+func DefaultEnvRetentionPolicy() RetentionPolicy {
+	var p RetentionPolicy
+
+	if v := os.Getenv("RETENTION_RAW_DAYS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			panic(fmt.Errorf("the RETENTION_RAW_DAYS env variable must be a number: %w", err))
+		}
+
+		p.RawDays = n
+	}
+
+	if v := os.Getenv("RETENTION_HOURLY_DAYS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			panic(fmt.Errorf("the RETENTION_HOURLY_DAYS env variable must be a number: %w", err))
+		}
+
+		p.HourlyDays = n
+	}
+
+	return p
+}
+
+// Enabled checks if the raw values are rolled up.
+func (p RetentionPolicy) Enabled() bool {
+	return p.RawDays > 0
+}
+
+// validate checks that the days are not negative and that the hourly aggregates are kept at least as
+// long as the raw values.
+func (p RetentionPolicy) validate() error {
+	if p.RawDays < 0 || p.HourlyDays < 0 {
+		return fmt.Errorf("the retention days (raw: %d, hourly: %d) must not be negative", p.RawDays, p.HourlyDays)
+	}
+
+	if p.HourlyDays > 0 && p.HourlyDays < p.RawDays {
+		return fmt.Errorf("the hourly retention (%d days) must not be shorter than the raw retention (%d days)", p.HourlyDays, p.RawDays)
+	}
+
+	return nil
+}
+
+// cutoffs returns the dates before which the raw values and the hourly aggregates are rolled up, they
+// are truncated to the hour and to the day in UTC so a bucket is rolled up at once. The hourly cutoff is
+// zero when the hourly aggregates are kept.
+func (p RetentionPolicy) cutoffs(now time.Time) (rawBefore, hourlyBefore time.Time) {
+	now = now.UTC()
+
+	rawBefore = repository.BucketHour.Truncate(now.AddDate(0, 0, -p.RawDays))
+
+	if p.HourlyDays > 0 {
+		hourlyBefore = repository.BucketDay.Truncate(now.AddDate(0, 0, -p.HourlyDays))
+	}
+
+	return rawBefore, hourlyBefore
+}
+
+// RunRetentionJob is a job that runs beside the provider job rolling up the currency values according to
+// the retention policy, the first rollup is made when the job starts and the next ones every hour. Like
+// the requests to the Currency Provider only the leader rolls up the values.
+func (s *Server) RunRetentionJob(ctx context.Context) {
+	if s.retention == nil {
+		return
+	}
+
+	s.logger.Info("Running the retention of the currency values",
+		zap.Int("raw_days", s.retention.RawDays),
+		zap.Int("hourly_days", s.retention.HourlyDays),
+	)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for {
+			select {
+			case <-ctx.Done():
+				log.Println("context is finishing the retention")
+
+				return
+			case <-timer.C:
+				// the lock is checked on each run, so the first rollup doesn't wait for the provider job
+				// and a replica that lost the lock stops rolling up
+				if s.lead(ctx) {
+					s.rollup(time.Now())
+				}
+
+				timer.Reset(retentionInterval)
+			}
+		}
+	}()
+
+	<-s.quitRetention
+
+	cancel()
+	<-done
+
+	log.Println("Retention Job is closed successfully")
+}
+
+// rollup rolls up the currency values older than the retention policy at now, an error is logged and
+// the values are rolled up by the next run.
+func (s *Server) rollup(now time.Time) {
+	rawBefore, hourlyBefore := s.retention.cutoffs(now)
+
+	start := time.Now()
+
+	result, err := s.currencyValue.Rollup(rawBefore, hourlyBefore)
+	if err != nil {
+		s.logger.Error("Failed to roll up the currency values", zap.Error(err))
+
+		return
+	}
+
+	s.logger.Info("Currency values rolled up",
+		zap.String("raw_before", rawBefore.Format(time.RFC3339)),
+		zap.Int64("hourly", result.Hourly),
+		zap.Int64("daily", result.Daily),
+		zap.Duration("elapsed", time.Since(start)),
+	)
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetentionPolicy(t *testing.T) {
+	now := time.Date(2022, 10, 19, 15, 42, 10, 0, time.UTC)
+
+	t.Run("cutoffs", func(t *testing.T) {
+		rawBefore, hourlyBefore := RetentionPolicy{RawDays: 7, HourlyDays: 90}.cutoffs(now)
+
+		assert.EqualValues(t, time.Date(2022, 10, 12, 15, 0, 0, 0, time.UTC), rawBefore)
+		assert.EqualValues(t, time.Date(2022, 7, 21, 0, 0, 0, 0, time.UTC), hourlyBefore)
+
+		_, hourlyBefore = RetentionPolicy{RawDays: 7}.cutoffs(now)
+
+		assert.True(t, hourlyBefore.IsZero(), "the hourly aggregates are kept")
+	})
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("RETENTION_RAW_DAYS", "30")
+		t.Setenv("RETENTION_HOURLY_DAYS", "365")
+
+		assert.EqualValues(t, RetentionPolicy{RawDays: 30, HourlyDays: 365}, DefaultEnvRetentionPolicy())
+
+		t.Setenv("RETENTION_RAW_DAYS", "a month")
+
+		assert.Panics(t, func() { DefaultEnvRetentionPolicy() })
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		assert.Panics(t, func() { Retention(RetentionPolicy{RawDays: -1}) })
+		assert.Panics(t, func() { Retention(RetentionPolicy{RawDays: 30, HourlyDays: 7}) })
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		s, _, closeFn := newTestServer(t, latestPayload)
+		defer closeFn()
+
+		s.WithOptions(Retention(RetentionPolicy{HourlyDays: 90}))
+
+		assert.Nil(t, s.retention)
+
+		// the job returns right away
+		s.RunRetentionJob(context.Background())
+	})
+}
+
+func TestRetentionJob(t *testing.T) {
+	s, repo, closeFn := newTestServer(t, latestPayload)
+	defer closeFn()
+
+	s.WithOptions(Retention(RetentionPolicy{RawDays: 1, HourlyDays: 3}))
+
+	now := time.Now().UTC()
+
+	// two snapshots in the same hour a week ago, one two days ago and the latest one
+	week := now.AddDate(0, 0, -7).Truncate(time.Hour)
+	seedSnapshot(t, repo, week, map[string]float64{"USD": 1, "MXN": 20})
+	seedSnapshot(t, repo, week.Add(time.Minute), map[string]float64{"USD": 1, "MXN": 22})
+	seedSnapshot(t, repo, now.AddDate(0, 0, -2), map[string]float64{"USD": 1, "MXN": 19})
+	seedSnapshot(t, repo, now, map[string]float64{"USD": 1, "MXN": 18})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go s.RunRetentionJob(ctx)
+
+	// only the latest snapshot is kept as raw values
+	assert.Eventually(t, func() bool {
+		vals, err := repo.CurrencyValue.ListCurrenciesByRequestID(3)
+
+		return err == nil && len(vals) == 0
+	}, 3*time.Second, 10*time.Millisecond)
+
+	s.quitRetention <- struct{}{}
+
+	vals, err := repo.CurrencyValue.ListCurrenciesByDateRange("MXN", nil, nil)
+
+	assert.Nil(t, err)
+	assert.Len(t, vals, 3)
+	assert.EqualValues(t, 4, vals[2].RequestID, "the latest value is kept raw")
+
+	// the week old hour was rolled up into its day, which is listed by its close
+	finit, fend := week.Add(-time.Second), week.Add(time.Hour)
+
+	vals, err = repo.CurrencyValue.ListCurrenciesByDateRange("MXN", &finit, &fend)
+
+	assert.Nil(t, err)
+	assert.Len(t, vals, 1)
+	assert.EqualValues(t, "22", vals[0].Value.String())
+	assert.EqualValues(t, week.Add(time.Minute), vals[0].LastUdatedAt)
+}
+
+func TestRetentionJobLeader(t *testing.T) {
+	s, repo, closeFn := newTestServer(t, latestPayload)
+	defer closeFn()
+
+	s.WithOptions(Retention(RetentionPolicy{RawDays: 1}))
+
+	// another replica is the leader
+	shared := &sharedLock{}
+	other := &sessionLock{shared: shared}
+
+	_, err := other.TryLock(context.Background())
+	assert.Nil(t, err)
+
+	s.locker = &sessionLock{shared: shared}
+
+	now := time.Now().UTC()
+	seedSnapshot(t, repo, now.AddDate(0, 0, -7), map[string]float64{"USD": 1, "MXN": 20})
+	seedSnapshot(t, repo, now, map[string]float64{"USD": 1, "MXN": 18})
+
+	rolledUp := func() bool {
+		vals, err := repo.CurrencyValue.ListCurrenciesByRequestID(1)
+
+		return err == nil && len(vals) == 0
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go s.RunRetentionJob(ctx)
+
+	assert.Never(t, rolledUp, 200*time.Millisecond, 10*time.Millisecond)
+
+	s.quitRetention <- struct{}{}
+
+	// the leader died, the first rollup takes the lock without waiting for the provider job
+	other.kill()
+
+	go s.RunRetentionJob(ctx)
+
+	assert.Eventually(t, rolledUp, 3*time.Second, 10*time.Millisecond)
+	assert.True(t, s.IsLeader())
+
+	s.quitRetention <- struct{}{}
+}
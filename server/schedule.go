@@ -0,0 +1,180 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PacoDw/currency/providers"
+	"github.com/PacoDw/currency/repository"
+	"github.com/robfig/cron/v3"
+)
+
+// cronParser parses the standard cron expressions with optional seconds and the descriptors like
+// @hourly or @every 15m, the timezone is set with the CRON_TZ= prefix, e.g.:
+// CRON_TZ=America/New_York */15 9-16 * * MON-FRI.
+var cronParser = cron.NewParser(
+	cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// schedule tells when the next request to the Currency Provider must be made.
+type schedule interface {
+	Next(t time.Time) time.Time
+	String() string
+}
+
+// intervalSchedule makes a request each interval, it is used when there is no cron schedule.
+type intervalSchedule time.Duration
+
+// Next returns the time after the interval.
+func (d intervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(d))
+}
+
+// String returns the schedule as a cron descriptor.
+func (d intervalSchedule) String() string {
+	return fmt.Sprintf("@every %s", time.Duration(d))
+}
+
+// cronSchedule is the union of several cron expressions, so different periods could have different
+// frequencies, e.g.: every 15 minutes during market hours and hourly on weekends.
+type cronSchedule struct {
+	specs     []string
+	schedules []cron.Schedule
+}
+
+// parseCronSchedule parses the cron expressions, the empty ones are skipped.
+func parseCronSchedule(specs ...string) (*cronSchedule, error) {
+	cs := &cronSchedule{}
+
+	for i := range specs {
+		spec := strings.TrimSpace(specs[i])
+		if spec == "" {
+			continue
+		}
+
+		sched, err := cronParser.Parse(spec)
+		if err != nil {
+			return nil, fmt.Errorf("the cron expression (%s) is invalid: %w", spec, err)
+		}
+
+		// e.g.: the 30th of February
+		if sched.Next(time.Now()).IsZero() {
+			return nil, fmt.Errorf("the cron expression (%s) never runs", spec)
+		}
+
+		cs.specs = append(cs.specs, spec)
+		cs.schedules = append(cs.schedules, sched)
+	}
+
+	return cs, nil
+}
+
+// Next returns the earliest time of all the cron expressions after t.
+func (cs *cronSchedule) Next(t time.Time) time.Time {
+	var earliest time.Time
+
+	for i := range cs.schedules {
+		next := cs.schedules[i].Next(t)
+
+		if !next.IsZero() && (earliest.IsZero() || next.Before(earliest)) {
+			earliest = next
+		}
+	}
+
+	return earliest
+}
+
+// String returns the cron expressions separated by semicolons.
+func (cs *cronSchedule) String() string {
+	return strings.Join(cs.specs, "; ")
+}
+
+// expectedSchedule is the schedule followed by the requests to the Currency Provider, that is the runs of
+// the job schedule without the ones skipped to make the quota last. The runs skipped by the last quota
+// plan are taken for any time.
+type expectedSchedule struct {
+	s *Server
+}
+
+// Next returns the first run after t that is not skipped.
+func (es expectedSchedule) Next(t time.Time) time.Time {
+	sched := es.s.jobSchedule()
+	next := sched.Next(t)
+
+	for i := es.s.skippedRuns(); i > 0; i-- {
+		next = sched.Next(next)
+	}
+
+	return next
+}
+
+// jobState holds the state of the provider job which is read by the routes.
+type jobState struct {
+	mu      sync.RWMutex
+	nextRun time.Time
+	skipped int64
+	leader  bool
+}
+
+// jobSchedule returns the cron schedule if it is set, otherwise the request interval.
+func (s *Server) jobSchedule() schedule {
+	if s.schedule != nil {
+		return s.schedule
+	}
+
+	return intervalSchedule(s.currencyRequestInterval)
+}
+
+// ExpectedSchedule returns when the requests to the Currency Provider are made, it follows the cron
+// schedule or the request interval and skips the runs left out by the quota.
+func (s *Server) ExpectedSchedule() repository.Schedule {
+	return expectedSchedule{s}
+}
+
+// NextRun returns the time of the next request planned to the Currency Provider, it is zero while the
+// job is not running.
+func (s *Server) NextRun() time.Time {
+	s.job.mu.RLock()
+	defer s.job.mu.RUnlock()
+
+	return s.job.nextRun
+}
+
+// setNextRun sets the time of the next request.
+func (s *Server) setNextRun(t time.Time) {
+	s.job.mu.Lock()
+	defer s.job.mu.Unlock()
+
+	s.job.nextRun = t
+}
+
+// skippedRuns returns the runs of the schedule skipped between two requests by the last quota plan.
+func (s *Server) skippedRuns() int64 {
+	s.job.mu.RLock()
+	defer s.job.mu.RUnlock()
+
+	return s.job.skipped
+}
+
+// setSkippedRuns sets the runs of the schedule skipped between two requests.
+func (s *Server) setSkippedRuns(n int64) {
+	s.job.mu.Lock()
+	defer s.job.mu.Unlock()
+
+	s.job.skipped = n
+}
+
+// staleAt returns the time when a request made at t is not fresh anymore, that is after freshnessFactor
+// planned requests. The requests are planned with the quota like the job does, so a job stretched or paused
+// to make the quota last is not stale.
+func (s *Server) staleAt(t time.Time, quota *providers.Quota) time.Time {
+	sched := s.jobSchedule()
+
+	for i := 0; i < freshnessFactor; i++ {
+		t = t.Add(planQuota(t, sched, quota).Interval)
+	}
+
+	return t
+}
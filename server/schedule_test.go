@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PacoDw/currency/providers"
+	"github.com/PacoDw/currency/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronSchedule(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+
+	// every 15 minutes during market hours and hourly on weekends
+	cs, err := parseCronSchedule(
+		"CRON_TZ=America/New_York */15 9-16 * * MON-FRI",
+		"",
+		"CRON_TZ=America/New_York 0 * * * SAT,SUN",
+	)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "CRON_TZ=America/New_York */15 9-16 * * MON-FRI; CRON_TZ=America/New_York 0 * * * SAT,SUN", cs.String())
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected time.Time
+	}{
+		{
+			name:     "market hours",
+			now:      time.Date(2022, 10, 18, 10, 5, 0, 0, ny), // Tuesday
+			expected: time.Date(2022, 10, 18, 10, 15, 0, 0, ny),
+		},
+		{
+			name:     "after the market closes",
+			now:      time.Date(2022, 10, 18, 16, 50, 0, 0, ny),
+			expected: time.Date(2022, 10, 19, 9, 0, 0, 0, ny),
+		},
+		{
+			name:     "friday night",
+			now:      time.Date(2022, 10, 21, 20, 0, 0, 0, ny),
+			expected: time.Date(2022, 10, 22, 0, 0, 0, 0, ny),
+		},
+		{
+			name:     "weekend",
+			now:      time.Date(2022, 10, 22, 10, 5, 0, 0, ny), // Saturday
+			expected: time.Date(2022, 10, 22, 11, 0, 0, 0, ny),
+		},
+		{
+			name:     "in another timezone",
+			now:      time.Date(2022, 10, 18, 14, 5, 0, 0, time.UTC),
+			expected: time.Date(2022, 10, 18, 10, 15, 0, 0, ny),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, tt.expected.Equal(cs.Next(tt.now)), cs.Next(tt.now).String())
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, spec := range []string{"* * *", "61 * * * *", "CRON_TZ=Nowhere/Land * * * * *", "0 0 30 2 *"} {
+			_, err := parseCronSchedule(spec)
+
+			assert.NotNil(t, err, spec)
+		}
+
+		assert.Panics(t, func() { CronSchedule("not a cron expression") })
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		repo := repository.NewMemoryConnection()
+
+		s := New(
+			Repository(repo.RequestStatus, repo.CurrencyValue),
+			CurrencyRequestInterval("1m"),
+			CronSchedule(""),
+		)
+
+		assert.EqualValues(t, "@every 1m0s", s.jobSchedule().String())
+
+		// an interval which never runs keeps the default one
+		s = New(
+			Repository(repo.RequestStatus, repo.CurrencyValue),
+			CurrencyRequestInterval("0s"),
+		)
+
+		assert.EqualValues(t, "@every 10s", s.jobSchedule().String())
+	})
+}
+
+func TestProviderJobRunsOnStartup(t *testing.T) {
+	s, repo, closeFn := newTestServer(t, latestPayload)
+	defer closeFn()
+
+	// the next run would be in an hour, so only the startup request is made
+	s.schedule, _ = parseCronSchedule("@every 1h")
+
+	assert.True(t, s.NextRun().IsZero())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go s.RunProviderJob(ctx)
+
+	assert.Eventually(t, func() bool {
+		return s.NextRun().After(time.Now().Add(59 * time.Minute))
+	}, 3*time.Second, 10*time.Millisecond)
+
+	s.quitCurrency <- struct{}{}
+
+	_, err := repo.RequestStatus.GetLatestByStatus("success")
+	assert.Nil(t, err)
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/status", http.NoBody))
+
+	var st status
+
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &st))
+	assert.EqualValues(t, "@every 1h", st.Schedule)
+	assert.NotNil(t, st.NextRunAt)
+	assert.True(t, st.NextRunAt.Equal(s.NextRun()))
+}
+
+// stoppedSchedule is a schedule whose runs are over, like a cron expression of a year gone by.
+type stoppedSchedule struct{}
+
+func (stoppedSchedule) Next(t time.Time) time.Time { return time.Time{} }
+
+func (stoppedSchedule) String() string { return "stopped" }
+
+func TestPlanNextRunWithoutNextRun(t *testing.T) {
+	s, _, closeFn := newTestServer(t, latestPayload)
+	defer closeFn()
+
+	s.schedule = stoppedSchedule{}
+
+	// the job waits the request interval instead of requesting right away
+	now := time.Date(2022, 10, 21, 23, 30, 0, 0, time.UTC)
+
+	assert.EqualValues(t, now.Add(50*time.Millisecond), s.planNextRun(now, nil))
+	assert.EqualValues(t, now.Add(50*time.Millisecond), s.planNextRun(now, &providers.Metadata{Quota: &providers.Quota{Limit: 300, Remaining: 10}}))
+}
+
+func TestExpectedSchedule(t *testing.T) {
+	s, _, closeFn := newTestServer(t, latestPayload)
+	defer closeFn()
+
+	s.schedule, _ = parseCronSchedule("CRON_TZ=UTC 0 * * * MON-FRI")
+
+	// friday night, the next run is on monday
+	now := time.Date(2022, 10, 21, 23, 30, 0, 0, time.UTC)
+	sched := s.ExpectedSchedule()
+
+	assert.EqualValues(t, time.Date(2022, 10, 24, 0, 0, 0, 0, time.UTC), sched.Next(now))
+
+	// every other run is made to save the quota
+	s.setSkippedRuns(1)
+
+	assert.EqualValues(t, time.Date(2022, 10, 24, 1, 0, 0, 0, time.UTC), sched.Next(now))
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/PacoDw/currency/logger"
+	"github.com/PacoDw/currency/metrics"
 	"github.com/PacoDw/currency/providers"
 	"github.com/PacoDw/currency/repository"
 	"github.com/go-chi/chi/v5"
@@ -24,10 +25,19 @@ type Server struct {
 	*chi.Mux
 	currencyClient          providers.Currencier
 	currencyRequestInterval time.Duration
-	repo                    *repository.SQLConnection
+	schedule                schedule
+	requestStatus           repository.RequestStatusRepository
+	currencyValue           repository.CurrencyValueRepository
+	database                repository.HealthChecker
+	locker                  repository.Locker
+	retention               *RetentionPolicy
 	logger                  *logger.Logger
+	metrics                 *metrics.Metrics
+	startedAt               time.Time
+	job                     *jobState
 
-	quitCurrency chan struct{}
+	quitCurrency  chan struct{}
+	quitRetention chan struct{}
 }
 
 // logRoutes is used by Zap Logger to register all the routes that the API has.
@@ -64,6 +74,11 @@ func (s *Server) Start() {
 	// run the Provider job to retrieve the data from the Currency Provider
 	go s.RunProviderJob(context.Background())
 
+	// run the Retention job beside it to roll up the old currency values
+	if s.retention != nil {
+		go s.RunRetentionJob(context.Background())
+	}
+
 	go func() {
 		if err := s.ListenAndServe(); err != nil && errors.Is(err, http.ErrServerClosed) {
 			s.logger.Fatal("Could not listen on", zap.String("addr", s.Addr), zap.Error(err))
@@ -82,6 +97,12 @@ func (s *Server) gracefulShutdown() {
 	signal.Notify(quit, os.Interrupt)
 
 	sig := <-quit
+
+	// the retention is stopped first so it doesn't take the lock released by the provider job
+	if s.retention != nil {
+		s.quitRetention <- struct{}{}
+	}
+
 	s.quitCurrency <- struct{}{}
 
 	s.logger.Info("Server is shutting down", zap.String("reason", sig.String()))
@@ -133,19 +154,40 @@ func New(opts ...Option) *Server {
 		nil,
 		10 * time.Second,
 		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
 		logger.NewLogger(logger.DefaultEnvLoggerConfig()),
+		metrics.New(),
+		time.Now(),
+		&jobState{},
+		make(chan struct{}),
 		make(chan struct{}),
 	}
 
 	// registered the first middleware as a required to log everything
 	router.Use(logger.ChiZapLoggerMiddleware(s.logger))
 
+	// counting the requests by route and status code
+	router.Use(s.metrics.Middleware)
+
 	// applying the options
 	s.WithOptions(opts...)
 
-	if s.repo == nil {
-		panic("the server.CurrencyProvider option must be set")
+	if s.requestStatus == nil || s.currencyValue == nil {
+		panic("the server.Repository option must be set")
 	}
 
+	// registering the health routes used by the orchestrator to route the traffic and restart
+	// the unhealthy instances
+	router.Get("/healthz", s.healthzRoute)
+	router.Get("/readyz", s.readyzRoute)
+	router.Get("/status", s.statusRoute)
+
+	// exposing the metrics in the Prometheus text format
+	router.Method(http.MethodGet, "/metrics", s.metrics.Handler())
+
 	return s
 }
@@ -0,0 +1,380 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PacoDw/currency/iso4217"
+	"github.com/PacoDw/currency/repository"
+	"github.com/PacoDw/currency/routes"
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// seedSnapshot inserts a request with the values of the currencies.
+func seedSnapshot(t *testing.T, repo *repository.MemoryConnection, lastUpdated time.Time, values map[string]float64) {
+	t.Helper()
+
+	id, err := repo.RequestStatus.Insert(repository.RequestStatus{Status: "success", RequestedAt: lastUpdated})
+	assert.Nil(t, err)
+
+	cvs := make([]repository.CurrencyValue, 0, len(values))
+
+	for name, v := range values {
+		cvs = append(cvs, repository.CurrencyValue{Name: name, RequestID: id, Value: decimal.NewFromFloat(v), LastUdatedAt: lastUpdated})
+	}
+
+	_, _, err = repo.CurrencyValue.BulkInsert(cvs)
+	assert.Nil(t, err)
+}
+
+func TestServerRoutes(t *testing.T) {
+	s, repo, closeFn := newTestServer(t, latestPayload)
+	defer closeFn()
+
+	s.Route("/currencies", func(r chi.Router) {
+		r.Use(ValidateDateTimeQueryParametersMiddleware([]routes.DateTimeQueryParameter{routes.Finit, routes.Fend}))
+
+		r.Get("/", routes.ListCurrenciesRoute())
+
+		r.
+			With(
+				ValidateRouteParametersMiddleware([]routes.RouteParameter{routes.Currency}, s.IsStoredCurrency),
+				ValidateBucketQueryParameterMiddleware(routes.Bucket),
+			).
+			Get("/{currency}", routes.CurrencyRoute(repo.CurrencyValue))
+
+		r.
+			With(ValidateRouteParametersMiddleware([]routes.RouteParameter{routes.Currency}, s.IsStoredCurrency)).
+			Get("/{currency}/latest", routes.LatestCurrencyRoute(repo.CurrencyValue))
+
+		r.
+			With(ValidateRouteParametersMiddleware([]routes.RouteParameter{routes.Currency}, s.IsStoredCurrency)).
+			Get("/{currency}/gaps", routes.CurrencyGapsRoute(repo.CurrencyValue, intervalSchedule(12*time.Hour)))
+	})
+
+	s.
+		With(
+			ValidateDateTimeQueryParametersMiddleware([]routes.DateTimeQueryParameter{routes.AsOf}),
+			ValidateCurrencyQueryParametersMiddleware([]routes.CurrencyQueryParameter{routes.From, routes.To}, s.IsStoredCurrency),
+			ValidateAmountQueryParameterMiddleware(routes.Amount),
+		).
+		Get("/convert", routes.ConvertRoute(repo.CurrencyValue))
+
+	s.Route("/requests", func(r chi.Router) {
+		r.
+			With(
+				ValidateDateTimeQueryParametersMiddleware([]routes.DateTimeQueryParameter{routes.Finit, routes.Fend}),
+				ValidatePaginationQueryParametersMiddleware([]routes.PaginationQueryParameter{routes.Limit, routes.Offset}),
+			).
+			Get("/", routes.ListRequestsStatusRoute(repo.RequestStatus))
+
+		r.
+			With(ValidateIDRouteParameterMiddleware(routes.ID)).
+			Get("/{id}", routes.RequestStatusRoute(repo.RequestStatus, repo.CurrencyValue))
+	})
+
+	seedSnapshot(t, repo, time.Date(2022, 10, 17, 23, 59, 59, 0, time.UTC), map[string]float64{"USD": 1, "MXN": 20, "EUR": 1.25})
+	seedSnapshot(t, repo, time.Date(2022, 10, 18, 23, 59, 59, 0, time.UTC), map[string]float64{"USD": 1, "MXN": 21, "EUR": 1.5, "JPY": 145.123})
+
+	t.Run("list currencies", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/currencies?status=withdrawn", http.NoBody))
+
+		assert.EqualValues(t, http.StatusOK, w.Code)
+
+		var currencies []iso4217.Currency
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &currencies))
+
+		assert.NotEmpty(t, currencies)
+
+		for i := range currencies {
+			assert.EqualValues(t, iso4217.Withdrawn, currencies[i].Status)
+		}
+
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/currencies?status=other", http.NoBody))
+
+		assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("unknown currency code", func(t *testing.T) {
+		for _, path := range []string{"/currencies/zzz", "/currencies/zzz/latest", "/convert?from=EUR&to=ZZZ&amount=3"} {
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, http.NoBody))
+
+			assert.EqualValues(t, http.StatusBadRequest, w.Code, path)
+		}
+	})
+
+	t.Run("currencies", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/currencies/mxn?finit=2022-10-18T00:00:00", http.NoBody))
+
+		assert.EqualValues(t, http.StatusOK, w.Code)
+
+		var vals []repository.CurrencyValue
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &vals))
+
+		assert.Len(t, vals, 1)
+		assert.EqualValues(t, "21", vals[0].Value.String())
+	})
+
+	t.Run("gaps", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/currencies/mxn/gaps", http.NoBody))
+
+		assert.EqualValues(t, http.StatusOK, w.Code)
+
+		var gaps []repository.CurrencyGap
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &gaps))
+
+		assert.Len(t, gaps, 1)
+		assert.EqualValues(t, "MXN", gaps[0].Name)
+		assert.EqualValues(t, time.Date(2022, 10, 17, 23, 59, 59, 0, time.UTC), gaps[0].From)
+		assert.EqualValues(t, "24h0m0s", gaps[0].Duration)
+		assert.EqualValues(t, 1, gaps[0].Missing)
+		assert.Empty(t, gaps[0].Failures)
+
+		// a single value has no gaps
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/currencies/jpy/gaps", http.NoBody))
+
+		assert.EqualValues(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, "[]", w.Body.String())
+	})
+
+	t.Run("currencies by bucket", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/currencies/mxn?bucket=1w", http.NoBody))
+
+		assert.EqualValues(t, http.StatusOK, w.Code)
+
+		var buckets []repository.CurrencyBucket
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &buckets))
+
+		assert.Len(t, buckets, 1)
+		assert.EqualValues(t, time.Date(2022, 10, 17, 0, 0, 0, 0, time.UTC), buckets[0].Bucket)
+		assert.EqualValues(t, "20", buckets[0].Open.String())
+		assert.EqualValues(t, "21", buckets[0].Close.String())
+		assert.EqualValues(t, "20.5", buckets[0].Avg.String())
+		assert.EqualValues(t, 2, buckets[0].Count)
+
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/currencies/mxn?bucket=5m", http.NoBody))
+
+		assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("latest", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/currencies/all/latest", http.NoBody))
+
+		assert.EqualValues(t, http.StatusOK, w.Code)
+
+		var vals []repository.CurrencyValue
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &vals))
+
+		assert.Len(t, vals, 4)
+		assert.EqualValues(t, "EUR", vals[0].Name)
+		assert.EqualValues(t, "1.5", vals[0].Value.String())
+		assert.EqualValues(t, 2, vals[0].RequestID)
+
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/currencies/mxn/latest", http.NoBody))
+
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &vals))
+
+		assert.Len(t, vals, 1)
+		assert.EqualValues(t, "21", vals[0].Value.String())
+	})
+
+	t.Run("convert latest snapshot", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/convert?from=EUR&to=mxn&amount=3", http.NoBody))
+
+		assert.EqualValues(t, http.StatusOK, w.Code)
+
+		var c routes.Conversion
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &c))
+
+		assert.EqualValues(t, "MXN", c.To)
+		assert.EqualValues(t, "42", c.Result.String())
+		assert.EqualValues(t, 2, c.RequestID)
+	})
+
+	t.Run("convert as of an older snapshot", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/convert?from=EUR&to=MXN&amount=3&as_of=2022-10-18T00:00:00", http.NoBody))
+
+		assert.EqualValues(t, http.StatusOK, w.Code)
+
+		var c routes.Conversion
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &c))
+
+		assert.EqualValues(t, "48", c.Result.String())
+		assert.EqualValues(t, 1, c.RequestID)
+	})
+
+	t.Run("convert rounds to the minor units", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/convert?from=EUR&to=JPY&amount=3", http.NoBody))
+
+		assert.EqualValues(t, http.StatusOK, w.Code)
+
+		var c routes.Conversion
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &c))
+
+		assert.EqualValues(t, "290", c.Result.String())
+	})
+
+	t.Run("convert currency without rates", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/convert?from=EUR&to=GBP&amount=3", http.NoBody))
+
+		assert.EqualValues(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("convert bad amount", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/convert?from=EUR&to=MXN&amount=-3", http.NoBody))
+
+		assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("requests", func(t *testing.T) {
+		_, err := repo.RequestStatus.Insert(repository.RequestStatus{
+			Status:       "failure",
+			RequestedAt:  time.Date(2022, 10, 19, 0, 0, 0, 0, time.UTC),
+			ErrorMessage: "unexpected status code 429",
+		})
+		assert.Nil(t, err)
+
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/requests", http.NoBody))
+
+		assert.EqualValues(t, http.StatusOK, w.Code)
+
+		var reqs []repository.RequestStatus
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &reqs))
+
+		assert.Len(t, reqs, 3)
+		assert.EqualValues(t, []int64{3, 2, 1}, []int64{reqs[0].ID, reqs[1].ID, reqs[2].ID})
+
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/requests?status=success&limit=1&offset=1", http.NoBody))
+
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &reqs))
+
+		assert.Len(t, reqs, 1)
+		assert.EqualValues(t, 1, reqs[0].ID)
+
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/requests?finit=2022-10-18T12:00:00&fend=2022-10-19T00:00:00", http.NoBody))
+
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &reqs))
+
+		assert.Len(t, reqs, 2)
+
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/requests?limit=-1", http.NoBody))
+
+		assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("request by id", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/requests/2", http.NoBody))
+
+		assert.EqualValues(t, http.StatusOK, w.Code)
+
+		var req routes.RequestStatusDetail
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &req))
+
+		assert.EqualValues(t, 2, req.ID)
+		assert.EqualValues(t, "success", req.Status)
+		assert.Len(t, req.CurrenciesValues, 4)
+		assert.EqualValues(t, "EUR", req.CurrenciesValues[0].Name)
+
+		for path, code := range map[string]int{"/requests/99": http.StatusNotFound, "/requests/abc": http.StatusBadRequest} {
+			w = httptest.NewRecorder()
+			s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, http.NoBody))
+
+			assert.EqualValues(t, code, w.Code, path)
+		}
+	})
+
+	t.Run("stored currency codes", func(t *testing.T) {
+		// a crypto currency stored by the provider job is not an ISO 4217 code
+		seedSnapshot(t, repo, time.Date(2022, 10, 19, 23, 59, 59, 0, time.UTC), map[string]float64{"USD": 1, "BTC": 0.00005})
+
+		for _, path := range []string{"/currencies/btc", "/currencies/btc/latest", "/convert?from=BTC&to=USD&amount=1"} {
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, http.NoBody))
+
+			assert.EqualValues(t, http.StatusOK, w.Code, path)
+		}
+
+		// 'all' still selects every currency instead of the Albanian Lek (ALL)
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/currencies/ALL/latest", http.NoBody))
+
+		assert.EqualValues(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"name":"BTC"`)
+	})
+}
+
+// brokenCurrencyValue fails like a repository without database, the other methods use the embedded one.
+type brokenCurrencyValue struct {
+	repository.CurrencyValueRepository
+}
+
+func (brokenCurrencyValue) ListLatestCurrencies(currency string) ([]repository.CurrencyValue, error) {
+	return nil, errors.New("dial tcp 127.0.0.1:5432: connect: connection refused")
+}
+
+func (brokenCurrencyValue) GetCrossRate(from, to string, asOf *time.Time) (repository.CrossRate, error) {
+	return repository.CrossRate{}, errors.New("dial tcp 127.0.0.1:5432: connect: connection refused")
+}
+
+func TestServerRoutesRepositoryErrors(t *testing.T) {
+	s, repo, closeFn := newTestServer(t, latestPayload)
+	defer closeFn()
+
+	broken := brokenCurrencyValue{repo.CurrencyValue}
+	s.currencyValue = broken
+
+	s.
+		With(ValidateRouteParametersMiddleware([]routes.RouteParameter{routes.Currency}, s.IsStoredCurrency)).
+		Get("/currencies/{currency}/latest", routes.LatestCurrencyRoute(broken))
+
+	s.
+		With(
+			ValidateDateTimeQueryParametersMiddleware([]routes.DateTimeQueryParameter{routes.AsOf}),
+			ValidateCurrencyQueryParametersMiddleware([]routes.CurrencyQueryParameter{routes.From, routes.To}, s.IsStoredCurrency),
+			ValidateAmountQueryParameterMiddleware(routes.Amount),
+		).
+		Get("/convert", routes.ConvertRoute(broken))
+
+	for _, path := range []string{"/currencies/MXN/latest", "/convert?from=EUR&to=MXN&amount=3"} {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, http.NoBody))
+
+		assert.EqualValues(t, http.StatusInternalServerError, w.Code, path)
+		assert.JSONEq(t, `{"error":"internal error"}`, w.Body.String(), path)
+	}
+
+	// the codes which are not ISO 4217 can't be checked
+	for _, path := range []string{"/currencies/BTC/latest", "/convert?from=BTC&to=MXN&amount=3"} {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, http.NoBody))
+
+		assert.EqualValues(t, http.StatusInternalServerError, w.Code, path)
+		assert.Contains(t, w.Body.String(), "failed to check", path)
+	}
+}